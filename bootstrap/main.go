@@ -4,28 +4,25 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"os"
 
 	"github.com/libp2p/go-libp2p"
-	crypto "github.com/libp2p/go-libp2p-crypto"
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	"github.com/multiformats/go-multiaddr"
-
-	// "crypto/rand"
-	"encoding/hex"
-	"io/ioutil"
-	"os"
-	"strings"
+	"github.com/sporeframework/spore/keystore"
 )
 
 func main() {
 	help := flag.Bool("help", false, "Display Help")
 	listenHost := flag.String("host", "0.0.0.0", "The bootstrap node host listen address\n")
 	port := flag.Int("port", 4001, "The bootstrap node listen port")
+	keystoreDir := flag.String("keystore", "keystore", "The directory of the keystore holding the bootstrap node identity")
+	passwordFile := flag.String("password-file", "", "File with the passphrase of the keystore. The passphrase is read from $SPORE_PASSPHRASE or prompted for if not set.")
 	flag.Parse()
 
 	if *help {
 		fmt.Printf("This is a simple bootstrap node for kad-dht application using libp2p\n\n")
-		fmt.Printf("Usage: \n   Run './bootnode'\nor Run './bootnode -host [host] -port [port]'\n")
+		fmt.Printf("Usage: \n   Run './bootnode'\nor Run './bootnode -host [host] -port [port] -keystore [dir] -password-file [file]'\n")
 
 		os.Exit(0)
 	}
@@ -35,33 +32,13 @@ func main() {
 	ctx := context.Background()
 	//r := mrand.New(mrand.NewSource(int64(*port)))
 
-	// Creates a new ECDSA key pair for this host.
-	/*
-		prvKey, _, err := crypto.GenerateECDSAKeyPair(rand.Reader)
-		if err != nil {
-			panic(err)
-		}
-
-		privB, err := prvKey.Bytes()
-		if err != nil {
-			panic(err)
-		}
-
-		fmt.Printf("private key: %x \n", string(privB))
-	*/
-
-	content, err := ioutil.ReadFile("key.txt")
-	if err != nil {
-		panic(err)
-	}
-
-	hexString := strings.TrimSuffix(string(content), "\n")
-	decoded, err := hex.DecodeString(hexString)
+	ks, err := keystore.New(*keystoreDir)
 	if err != nil {
 		panic(err)
 	}
 
-	privNew, err := crypto.UnmarshalPrivateKey(decoded)
+	// a plaintext key.txt of previous versions is encrypted into the keystore
+	privNew, err := keystore.UnlockNodeIdentity(ks, *passwordFile, "key.txt")
 	if err != nil {
 		panic(err)
 	}
@@ -21,7 +21,7 @@ do
         output_name+='.exe'
     fi
 
-    env GOOS=$GOOS GOARCH=$GOARCH go build -o ${output_name} ../
+    env GOOS=$GOOS GOARCH=$GOARCH go build -o ${output_name} ../cmd/spore
     if [ $? -ne 0 ]; then
         echo 'An error has occurred! Aborting the script execution...'
         exit 1
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// argHelp documents the encoding of call arguments and results
+const argHelp = `Arguments are TYPE:VALUE, where TYPE is one of
+  str    UTF-8 string, the default when there is no TYPE
+  hex    hex-encoded bytes, also written 0x...
+  i32    32-bit integer, little-endian like the i32 results of contracts
+  i64    64-bit integer, little-endian
+  f32    32-bit float, little-endian
+  f64    64-bit float, little-endian
+`
+
+// encodeArg encodes a call argument given as TYPE:VALUE
+func encodeArg(arg string) ([]byte, error) {
+	if strings.HasPrefix(arg, "0x") {
+		return hex.DecodeString(arg[2:])
+	}
+
+	typ, value := "str", arg
+	if i := strings.Index(arg, ":"); i >= 0 {
+		typ, value = arg[:i], arg[i+1:]
+	}
+
+	switch typ {
+	case "str":
+		return []byte(value), nil
+	case "hex":
+		return hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	case "i32":
+		v, err := strconv.ParseInt(value, 0, 32)
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, uint32(v))
+		return b, nil
+	case "i64":
+		v, err := strconv.ParseInt(value, 0, 64)
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, uint64(v))
+		return b, nil
+	case "f32":
+		v, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, math.Float32bits(float32(v)))
+		return b, nil
+	case "f64":
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, math.Float64bits(v))
+		return b, nil
+	default:
+		// a string that happens to contain a colon
+		return []byte(arg), nil
+	}
+}
+
+func encodeArgs(args []string) ([][]byte, error) {
+	encoded := make([][]byte, len(args))
+	for i, arg := range args {
+		b, err := encodeArg(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument %q: %s", arg, err)
+		}
+		encoded[i] = b
+	}
+	return encoded, nil
+}
+
+// decodeResult formats a call result as the type of the --result flag
+func decodeResult(typ string, result []byte) (string, error) {
+	switch typ {
+	case "hex":
+		return hex.EncodeToString(result), nil
+	case "str":
+		return string(result), nil
+	case "i32":
+		if len(result) != 4 {
+			return "", fmt.Errorf("result of %d bytes is not an i32", len(result))
+		}
+		return strconv.FormatInt(int64(int32(binary.LittleEndian.Uint32(result))), 10), nil
+	case "i64":
+		if len(result) != 8 {
+			return "", fmt.Errorf("result of %d bytes is not an i64", len(result))
+		}
+		return strconv.FormatInt(int64(binary.LittleEndian.Uint64(result)), 10), nil
+	case "f32":
+		if len(result) != 4 {
+			return "", fmt.Errorf("result of %d bytes is not an f32", len(result))
+		}
+		return strconv.FormatFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(result))), 'g', -1, 32), nil
+	case "f64":
+		if len(result) != 8 {
+			return "", fmt.Errorf("result of %d bytes is not an f64", len(result))
+		}
+		return strconv.FormatFloat(math.Float64frombits(binary.LittleEndian.Uint64(result)), 'g', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unknown result type %q", typ)
+	}
+}
+
+// decodeHex decodes a hex argument such as an id or an address, with or
+// without 0x
+func decodeHex(name, s string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %s", name, s, err)
+	}
+	return b, nil
+}
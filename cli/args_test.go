@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeArg(t *testing.T) {
+	tests := []struct {
+		arg      string
+		expected []byte
+	}{
+		{"hello", []byte("hello")},
+		{"str:i32:1", []byte("i32:1")},
+		{"a:b", []byte("a:b")},
+		{"0x0a0b", []byte{0x0a, 0x0b}},
+		{"hex:0a0b", []byte{0x0a, 0x0b}},
+		{"i32:-2", []byte{0xfe, 0xff, 0xff, 0xff}},
+		{"i64:258", []byte{2, 1, 0, 0, 0, 0, 0, 0}},
+		{"f32:1", []byte{0, 0, 0x80, 0x3f}},
+	}
+	for _, test := range tests {
+		b, err := encodeArg(test.arg)
+		if err != nil {
+			t.Errorf("%s: failed to encode: %s", test.arg, err)
+			continue
+		}
+		if !bytes.Equal(b, test.expected) {
+			t.Errorf("%s: expected %x, got %x", test.arg, test.expected, b)
+		}
+	}
+
+	if _, err := encodeArg("i32:99999999999"); err == nil {
+		t.Error("expected out of range i32 to be rejected")
+	}
+}
+
+func TestDecodeResult(t *testing.T) {
+	for _, typ := range []string{"i32", "i64", "f32", "f64", "str", "hex"} {
+		b, err := encodeArg(typ + ":07")
+		if err != nil {
+			t.Fatalf("%s: failed to encode: %s", typ, err)
+		}
+		s, err := decodeResult(typ, b)
+		if err != nil || (s != "7" && s != "07") {
+			t.Errorf("%s: expected 7, got %q (%v)", typ, s, err)
+		}
+	}
+
+	if _, err := decodeResult("i32", []byte{1}); err == nil {
+		t.Error("expected result of the wrong size to be rejected")
+	}
+}
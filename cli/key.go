@@ -0,0 +1,263 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/sporeframework/spore/client"
+	"github.com/sporeframework/spore/datadir"
+	"github.com/sporeframework/spore/keystore"
+)
+
+// flags of the commands that use the keystore
+var (
+	keystoreDir    = filepath.Join(datadir.Resolve(""), "keystore")
+	passphraseFile string
+)
+
+func addKeystoreFlags(fs *flag.FlagSet) {
+	fs.StringVar(&keystoreDir, "keystore", keystoreDir, "Directory of the keystore.")
+	fs.StringVar(&passphraseFile, "password-file", passphraseFile, "File holding the passphrase of the keys, read from "+keystore.PassphraseEnv+" if that is set, or else prompted for. New keys need a non-empty passphrase.")
+}
+
+func openKeyStore() (*keystore.KeyStore, error) {
+	return keystore.New(keystoreDir)
+}
+
+func runKey(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: key new|import|export|list|address|rotate-node")
+	}
+
+	switch args[0] {
+	case "new":
+		return runKeyNew(args[1:])
+	case "import":
+		return runKeyImport(args[1:])
+	case "export":
+		return runKeyExport(args[1:])
+	case "list":
+		return runKeyList(args[1:])
+	case "address":
+		return runKeyAddress(args[1:])
+	case "rotate-node":
+		return runKeyRotateNode(args[1:])
+	default:
+		return fmt.Errorf("unknown key command %q", args[0])
+	}
+}
+
+func runKeyNew(args []string) error {
+	fs := newFlagSet("key new", "[flags] NAME")
+	addKeystoreFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("a key name is required")
+	}
+
+	ks, err := openKeyStore()
+	if err != nil {
+		return err
+	}
+	passphrase, err := keystore.Passphrase(passphraseFile, "New passphrase: ", true)
+	if err != nil {
+		return err
+	}
+	prv, err := ks.NewAccount(fs.Arg(0), passphrase)
+	if err != nil {
+		return err
+	}
+	return printKey(fs.Arg(0), crypto.PubkeyToAddress(prv.PublicKey).Bytes())
+}
+
+func runKeyImport(args []string) error {
+	fs := newFlagSet("key import", "[flags] NAME [PRIVATE_KEY]")
+	addKeystoreFlags(fs)
+	file := fs.String("file", "", "Keystore v3 file to import instead of a hex private key.")
+	fs.Parse(args)
+	if fs.NArg() != 1 && fs.NArg() != 2 || (fs.NArg() == 2) == (*file != "") {
+		fs.Usage()
+		return errors.New("a key name and either a hex private key or --file are required")
+	}
+	name := fs.Arg(0)
+
+	ks, err := openKeyStore()
+	if err != nil {
+		return err
+	}
+
+	// v3 files keep their passphrase, hex keys are encrypted with a new one
+	if *file != "" {
+		keyJSON, err := ioutil.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		passphrase, err := keystore.Passphrase(passphraseFile, "Passphrase of the key file: ", false)
+		if err != nil {
+			return err
+		}
+		if err = ks.ImportAccountJSON(name, keyJSON, passphrase); err != nil {
+			return err
+		}
+		prv, err := ks.Account(name, passphrase)
+		if err != nil {
+			return err
+		}
+		return printKey(name, crypto.PubkeyToAddress(prv.PublicKey).Bytes())
+	}
+
+	prv, err := crypto.HexToECDSA(strings.TrimPrefix(fs.Arg(1), "0x"))
+	if err != nil {
+		return fmt.Errorf("invalid private key: %s", err)
+	}
+	passphrase, err := keystore.Passphrase(passphraseFile, "New passphrase: ", true)
+	if err != nil {
+		return err
+	}
+	if err = ks.ImportAccount(name, prv, passphrase); err != nil {
+		return err
+	}
+	return printKey(name, crypto.PubkeyToAddress(prv.PublicKey).Bytes())
+}
+
+func runKeyExport(args []string) error {
+	fs := newFlagSet("key export", "[flags] NAME")
+	addKeystoreFlags(fs)
+	out := fs.String("out", "", "File to write the keystore v3 file to. The file is printed if not set.")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("a key name is required")
+	}
+
+	ks, err := openKeyStore()
+	if err != nil {
+		return err
+	}
+	keyJSON, err := ks.ExportAccountJSON(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		fmt.Println(string(keyJSON))
+		return nil
+	}
+
+	f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(keyJSON); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "🔐 Exported key to", *out)
+	return nil
+}
+
+func runKeyList(args []string) error {
+	fs := newFlagSet("key list", "[flags]")
+	addKeystoreFlags(fs)
+	fs.Parse(args)
+
+	ks, err := openKeyStore()
+	if err != nil {
+		return err
+	}
+	names, err := ks.Accounts()
+	if err != nil {
+		return err
+	}
+	if names == nil {
+		names = []string{}
+	}
+	return output(map[string][]string{"keys": names}, func() {
+		for _, name := range names {
+			fmt.Println(name)
+		}
+	})
+}
+
+func runKeyAddress(args []string) error {
+	fs := newFlagSet("key address", "[flags] NAME")
+	addKeystoreFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("a key name is required")
+	}
+
+	signer, err := loadSigner(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return printKey(fs.Arg(0), signer.Address())
+}
+
+func runKeyRotateNode(args []string) error {
+	fs := newFlagSet("key rotate-node", "[flags]")
+	addKeystoreFlags(fs)
+	fs.Parse(args)
+
+	ks, err := openKeyStore()
+	if err != nil {
+		return err
+	}
+	passphrase, err := keystore.Passphrase(passphraseFile, "Passphrase of the node identity: ", false)
+	if err != nil {
+		return err
+	}
+	prv, err := ks.RotateNodeIdentity(passphrase)
+	if err != nil {
+		return err
+	}
+	id, err := peer.IDFromPrivateKey(prv)
+	if err != nil {
+		return err
+	}
+
+	view := map[string]string{"peerId": id.Pretty()}
+	return output(view, func() {
+		fmt.Println("🔑 New node identity:", id.Pretty())
+		fmt.Println("The node uses it from its next start.")
+	})
+}
+
+func printKey(name string, address []byte) error {
+	view := map[string]string{"name": name, "address": hexBytes(address).String()}
+	return output(view, func() {
+		fmt.Println("Name:   ", name)
+		fmt.Println("Address:", view["address"])
+	})
+}
+
+// loadSigner unlocks the account key of the --key flag
+func loadSigner(name string) (client.Signer, error) {
+	if name == "" {
+		return nil, errors.New("--key is required")
+	}
+	ks, err := openKeyStore()
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := keystore.Passphrase(passphraseFile, fmt.Sprintf("Passphrase of %s: ", name), false)
+	if err != nil {
+		return nil, err
+	}
+	prv, err := ks.Account(name, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock key: %s", err)
+	}
+	return client.NewSigner(prv), nil
+}
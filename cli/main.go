@@ -0,0 +1,120 @@
+// Command spore-cli is the command-line tool of spore nodes. It manages keys,
+// deploys, calls and queries contracts, and inspects transactions, receipts,
+// the DAG and the node through the RPC interface of a node.
+//
+// Build it with
+//
+//	go build -o spore-cli ./cli
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sporeframework/spore/client"
+)
+
+const programName = "spore-cli"
+
+// command is a subcommand of the tool
+type command struct {
+	name string
+	args string
+	help string
+	run  func(args []string) error
+}
+
+var commands = []*command{
+	{name: "key", args: "new|import|export|list|address|rotate-node ...", help: "Manage the keys of the keystore", run: runKey},
+	{name: "deploy", args: "[flags] WASM", help: "Deploy a contract from a wasm file", run: runDeploy},
+	{name: "call", args: "[flags] CONTRACT FUNCTION [ARG...]", help: "Call a function of a contract in a transaction", run: runCall},
+	{name: "query", args: "[flags] CONTRACT FUNCTION [ARG...]", help: "Call a function of a contract without a transaction", run: runQuery},
+	{name: "transfer", args: "[flags] ADDRESS VALUE", help: "Transfer value to an account", run: runTransfer},
+	{name: "balance", args: "[flags] ADDRESS", help: "Show the balance of an account", run: runBalance},
+	{name: "tx", args: "[flags] ID", help: "Show a transaction", run: runTx},
+	{name: "receipt", args: "[flags] ID", help: "Show the receipt of an executed transaction", run: runReceipt},
+	{name: "dag", args: "tips|order|height ...", help: "Inspect the DAG", run: runDag},
+	{name: "peers", args: "[flags]", help: "List the peers of the node", run: runPeers},
+	{name: "status", args: "[flags]", help: "Show the status of the node", run: runStatus},
+}
+
+// flags shared by all commands
+var (
+	rpcEndpoints = "localhost:9000"
+	useTLS       bool
+	jsonOutput   bool
+	timeout      = 30 * time.Second
+)
+
+func addCommonFlags(fs *flag.FlagSet) {
+	fs.StringVar(&rpcEndpoints, "rpc", rpcEndpoints, "Comma-separated RPC endpoints of nodes, tried in order.")
+	fs.BoolVar(&useTLS, "tls", useTLS, "Connect to the nodes over TLS.")
+	fs.BoolVar(&jsonOutput, "json", jsonOutput, "Print the output as JSON.")
+	fs.DurationVar(&timeout, "timeout", timeout, "How long to wait for the nodes.")
+}
+
+// newFlagSet returns the flags of a command, including the common flags
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	addCommonFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s %s\n\nFlags:\n", programName, name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] COMMAND [ARGS...]\n\nCommands:\n", programName)
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-10s %s\n", cmd.name, cmd.help)
+	}
+	fmt.Fprintf(out, "\nRun '%s COMMAND -h' for the flags of a command.\n\nFlags:\n", programName)
+	flag.PrintDefaults()
+}
+
+// dial connects to the nodes of the --rpc flag
+func dial(ctx context.Context, opts ...client.Option) (*client.Client, error) {
+	if useTLS {
+		opts = append(opts, client.WithTLS(&tls.Config{}))
+	}
+	return client.Dial(ctx, strings.Split(rpcEndpoints, ","), opts...)
+}
+
+// commandContext returns the context of a command, done after --timeout
+func commandContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+func main() {
+	addCommonFlags(flag.CommandLine)
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := flag.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if err := cmd.run(flag.Args()[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
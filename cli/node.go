@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+func runDag(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: dag tips|order|height")
+	}
+
+	switch args[0] {
+	case "tips":
+		return runDagTips(args[1:])
+	case "order":
+		return runDagOrder(args[1:])
+	case "height":
+		return runDagHeight(args[1:])
+	default:
+		return fmt.Errorf("unknown dag command %q", args[0])
+	}
+}
+
+func runDagTips(args []string) error {
+	fs := newFlagSet("dag tips", "[flags]")
+	fs.Parse(args)
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	c, err := dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	tips, err := c.Tips(ctx)
+	if err != nil {
+		return err
+	}
+	view := map[string][]hexBytes{"tips": hexList(tips)}
+	return output(view, func() {
+		for _, tip := range view["tips"] {
+			fmt.Println(tip)
+		}
+	})
+}
+
+func runDagOrder(args []string) error {
+	fs := newFlagSet("dag order", "[flags]")
+	start := fs.Uint64("start", 0, "Order index of the first transaction.")
+	limit := fs.Uint("limit", 100, "Most transactions listed, all of them if 0.")
+	fs.Parse(args)
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	c, err := dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	ids, size, err := c.Order(ctx, *start, uint32(*limit))
+	if err != nil {
+		return err
+	}
+	view := map[string]interface{}{"start": *start, "size": size, "transactions": hexList(ids)}
+	return output(view, func() {
+		for i, id := range ids {
+			fmt.Printf("%d\t%s\n", *start+uint64(i), hexBytes(id))
+		}
+		fmt.Printf("%d of %d transactions\n", len(ids), size)
+	})
+}
+
+func runDagHeight(args []string) error {
+	fs := newFlagSet("dag height", "[flags] [ID]")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errors.New("at most one transaction id is allowed")
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	c, err := dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	// without an id, the height of the DAG is the height of its coloring tip
+	if fs.NArg() == 0 {
+		status, err := c.Status(ctx)
+		if err != nil {
+			return err
+		}
+		view := map[string]interface{}{
+			"coloringTip": hexBytes(status.ColoringTip),
+			"height":      status.Height,
+			"blueScore":   status.BlueScore,
+		}
+		return output(view, func() {
+			fmt.Println("Height:      ", status.Height)
+			fmt.Println("Blue score:  ", status.BlueScore)
+			fmt.Println("Coloring tip:", hexBytes(status.ColoringTip))
+		})
+	}
+
+	id, err := decodeHex("transaction id", fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	node, err := c.DagNode(ctx, id)
+	if err != nil {
+		return err
+	}
+	view := map[string]interface{}{
+		"id":        hexBytes(node.Id),
+		"height":    node.Height,
+		"blueScore": node.BlueScore,
+		"parents":   hexList(node.Parents),
+	}
+	return output(view, func() {
+		fmt.Println("Height:    ", node.Height)
+		fmt.Println("Blue score:", node.BlueScore)
+		for _, p := range node.Parents {
+			fmt.Println("Parent:    ", hexBytes(p))
+		}
+	})
+}
+
+func runPeers(args []string) error {
+	fs := newFlagSet("peers", "[flags]")
+	fs.Parse(args)
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	c, err := dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	peers, err := c.Peers(ctx)
+	if err != nil {
+		return err
+	}
+
+	type peerView struct {
+		ID        string   `json:"id"`
+		Addresses []string `json:"addresses"`
+	}
+	view := make([]peerView, len(peers))
+	for i, p := range peers {
+		view[i] = peerView{ID: p.Id, Addresses: p.Addresses}
+	}
+	return output(view, func() {
+		for _, p := range view {
+			fmt.Printf("%s\t%s\n", p.ID, strings.Join(p.Addresses, ", "))
+		}
+		fmt.Printf("%d peers\n", len(view))
+	})
+}
+
+func runStatus(args []string) error {
+	fs := newFlagSet("status", "[flags]")
+	fs.Parse(args)
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	c, err := dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	status, err := c.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	view := map[string]interface{}{
+		"peerId":        status.PeerId,
+		"peers":         status.Peers,
+		"transactions":  status.Transactions,
+		"tips":          status.Tips,
+		"coloringTip":   hexBytes(status.ColoringTip),
+		"blueScore":     status.BlueScore,
+		"height":        status.Height,
+		"executedIndex": status.ExecutedIndex,
+		"pending":       status.Pending,
+	}
+	return output(view, func() {
+		fmt.Println("🌟 Id:         ", status.PeerId)
+		fmt.Println("Peers:         ", status.Peers)
+		fmt.Println("Transactions:  ", status.Transactions)
+		fmt.Println("Tips:          ", status.Tips)
+		fmt.Println("Height:        ", status.Height)
+		fmt.Println("Blue score:    ", status.BlueScore)
+		fmt.Println("Coloring tip:  ", hexBytes(status.ColoringTip))
+		fmt.Println("Executed:      ", status.ExecutedIndex)
+		fmt.Println("Pending:       ", status.Pending)
+	})
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	pb "github.com/sporeframework/spore/protocol"
+)
+
+// hexBytes are bytes shown as hex in the JSON output
+type hexBytes []byte
+
+func (b hexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(b))
+}
+
+func (b hexBytes) String() string {
+	return hex.EncodeToString(b)
+}
+
+func hexList(list [][]byte) []hexBytes {
+	out := make([]hexBytes, len(list))
+	for i, b := range list {
+		out[i] = b
+	}
+	return out
+}
+
+// output prints v as JSON with --json, and runs text otherwise
+func output(v interface{}, text func()) error {
+	if !jsonOutput {
+		text()
+		return nil
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+type transactionView struct {
+	ID        hexBytes   `json:"id"`
+	From      hexBytes   `json:"from"`
+	To        hexBytes   `json:"to"`
+	Data      hexBytes   `json:"data"`
+	Gas       int64      `json:"gas"`
+	GasPrice  int64      `json:"gasPrice"`
+	Nonce     int32      `json:"nonce"`
+	Value     int64      `json:"value"`
+	Contract  bool       `json:"contract"`
+	Parents   []hexBytes `json:"parents"`
+	Created   int64      `json:"created"`
+	Scheme    string     `json:"scheme"`
+	Signature hexBytes   `json:"signature"`
+}
+
+func printTransaction(txn *pb.Transaction) error {
+	view := &transactionView{
+		ID:        txn.Id,
+		From:      txn.From,
+		To:        txn.To,
+		Data:      txn.Data,
+		Gas:       txn.Gas,
+		GasPrice:  txn.GasPrice,
+		Nonce:     txn.Nonce,
+		Value:     txn.Value,
+		Contract:  txn.Contract,
+		Parents:   hexList(txn.Parents),
+		Created:   txn.Created,
+		Scheme:    txn.Scheme.String(),
+		Signature: txn.Signature,
+	}
+	return output(view, func() {
+		fmt.Println("Transaction:", view.ID)
+		fmt.Println("From:       ", view.From)
+		fmt.Println("To:         ", view.To)
+		fmt.Println("Value:      ", view.Value)
+		fmt.Println("Gas:        ", view.Gas)
+		fmt.Println("Gas price:  ", view.GasPrice)
+		fmt.Println("Nonce:      ", view.Nonce)
+		fmt.Println("Created:    ", view.Created)
+		fmt.Println("Scheme:     ", view.Scheme)
+		fmt.Printf("Data:        %d bytes\n", len(view.Data))
+		for _, p := range view.Parents {
+			fmt.Println("Parent:     ", p)
+		}
+	})
+}
+
+type eventView struct {
+	Contract hexBytes `json:"contract"`
+	Topic    hexBytes `json:"topic"`
+	Data     hexBytes `json:"data"`
+}
+
+type receiptView struct {
+	TransactionID hexBytes    `json:"transactionId"`
+	Status        string      `json:"status"`
+	Index         uint64      `json:"index"`
+	GasUsed       int64       `json:"gasUsed"`
+	Result        hexBytes    `json:"result"`
+	Error         string      `json:"error,omitempty"`
+	Events        []eventView `json:"events"`
+}
+
+func printReceipt(receipt *pb.Receipt) error {
+	view := &receiptView{
+		TransactionID: receipt.TransactionId,
+		Status:        receipt.Status.String(),
+		Index:         receipt.Index,
+		GasUsed:       receipt.GasUsed,
+		Result:        receipt.Result,
+		Error:         receipt.Error,
+		Events:        []eventView{},
+	}
+	for _, e := range receipt.Events {
+		view.Events = append(view.Events, eventView{Contract: e.Contract, Topic: e.Topic, Data: e.Data})
+	}
+	return output(view, func() {
+		fmt.Println("Transaction:", view.TransactionID)
+		fmt.Println("Status:     ", view.Status)
+		if view.Error != "" {
+			fmt.Println("Error:      ", view.Error)
+		}
+		fmt.Println("Order index:", view.Index)
+		fmt.Println("Gas used:   ", view.GasUsed)
+		fmt.Println("Result:     ", view.Result)
+		for _, e := range view.Events {
+			fmt.Printf("Event:       contract %s topic %s data %s\n", e.Contract, e.Topic, e.Data)
+		}
+	})
+}
+
+// printSent prints the id of a sent transaction, or its receipt if the command
+// waited for it
+func printSent(id []byte, receipt *pb.Receipt) error {
+	if receipt != nil {
+		return printReceipt(receipt)
+	}
+
+	view := map[string]hexBytes{"transactionId": id}
+	return output(view, func() {
+		fmt.Println("Transaction:", hexBytes(id))
+	})
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/sporeframework/spore/client"
+	pb "github.com/sporeframework/spore/protocol"
+)
+
+// sendFlags are the flags of the commands that send a transaction
+type sendFlags struct {
+	key      *string
+	gas      *int64
+	gasPrice *int64
+	wait     *bool
+}
+
+func addSendFlags(fs *flag.FlagSet, gas int64) *sendFlags {
+	addKeystoreFlags(fs)
+	return &sendFlags{
+		key:      fs.String("key", "", "Name of the key of the sender in the keystore."),
+		gas:      fs.Int64("gas", gas, "Gas limit of the transaction."),
+		gasPrice: fs.Int64("gas-price", 1, "Price paid per unit of gas."),
+		wait:     fs.Bool("wait", false, "Wait for the transaction to be executed and print its receipt."),
+	}
+}
+
+// send connects to the nodes, sends a transaction with send and waits for its
+// receipt if --wait is set
+func (f *sendFlags) send(send func(ctx context.Context, c *client.Client, signer client.Signer) ([]byte, error)) ([]byte, *pb.Receipt, error) {
+	signer, err := loadSigner(*f.key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	c, err := dial(ctx, client.WithGasPrice(*f.gasPrice))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer c.Close()
+
+	id, err := send(ctx, c, signer)
+	if err != nil || !*f.wait {
+		return id, nil, err
+	}
+	receipt, err := c.WaitForReceipt(ctx, id)
+	return id, receipt, err
+}
+
+func runDeploy(args []string) error {
+	fs := newFlagSet("deploy", "[flags] WASM")
+	flags := addSendFlags(fs, 100000)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("a wasm file is required")
+	}
+
+	wasm, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var contractID [32]byte
+	id, receipt, err := flags.send(func(ctx context.Context, c *client.Client, signer client.Signer) (id []byte, err error) {
+		id, contractID, err = c.Deploy(ctx, signer, wasm, *flags.gas)
+		return id, err
+	})
+	if err != nil {
+		return err
+	}
+	if receipt != nil {
+		return printReceipt(receipt)
+	}
+
+	view := map[string]hexBytes{"transactionId": id, "contractId": contractID[:]}
+	return output(view, func() {
+		fmt.Println("Transaction:", hexBytes(id))
+		fmt.Println("Contract:   ", hexBytes(contractID[:]))
+	})
+}
+
+func runCall(args []string) error {
+	fs := newFlagSet("call", "[flags] CONTRACT FUNCTION [ARG...]\n\n"+argHelp)
+	flags := addSendFlags(fs, 100000)
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return errors.New("a contract and a function are required")
+	}
+
+	contractID, err := decodeHex("contract id", fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	callArgs, err := encodeArgs(fs.Args()[2:])
+	if err != nil {
+		return err
+	}
+
+	id, receipt, err := flags.send(func(ctx context.Context, c *client.Client, signer client.Signer) ([]byte, error) {
+		return c.Call(ctx, signer, contractID, *flags.gas, fs.Arg(1), callArgs...)
+	})
+	if err != nil {
+		return err
+	}
+	return printSent(id, receipt)
+}
+
+func runTransfer(args []string) error {
+	fs := newFlagSet("transfer", "[flags] ADDRESS VALUE")
+	flags := addSendFlags(fs, 100)
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("an address and a value are required")
+	}
+
+	to, err := decodeHex("address", fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	value, err := strconv.ParseInt(fs.Arg(1), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid value %q: %s", fs.Arg(1), err)
+	}
+
+	id, receipt, err := flags.send(func(ctx context.Context, c *client.Client, signer client.Signer) ([]byte, error) {
+		return c.Transfer(ctx, signer, to, value, *flags.gas)
+	})
+	if err != nil {
+		return err
+	}
+	return printSent(id, receipt)
+}
+
+func runQuery(args []string) error {
+	fs := newFlagSet("query", "[flags] CONTRACT FUNCTION [ARG...]\n\n"+argHelp)
+	from := fs.String("from", "", "Address of the caller seen by the contract.")
+	gas := fs.Int64("gas", 0, "Gas limit of the call, the node's limit if 0.")
+	result := fs.String("result", "hex", "Type of the result: hex, str, i32, i64, f32 or f64.")
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return errors.New("a contract and a function are required")
+	}
+
+	contractID, err := decodeHex("contract id", fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var caller []byte
+	if *from != "" {
+		if caller, err = decodeHex("address", *from); err != nil {
+			return err
+		}
+	}
+	callArgs, err := encodeArgs(fs.Args()[2:])
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	c, err := dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	res, gasUsed, err := c.Query(ctx, contractID, caller, *gas, fs.Arg(1), callArgs...)
+	if err != nil {
+		return err
+	}
+	decoded, err := decodeResult(*result, res)
+	if err != nil {
+		return err
+	}
+
+	view := map[string]interface{}{"result": hexBytes(res), "decoded": decoded, "gasUsed": gasUsed}
+	return output(view, func() {
+		fmt.Println("Result:  ", decoded)
+		fmt.Println("Gas used:", gasUsed)
+	})
+}
+
+func runBalance(args []string) error {
+	fs := newFlagSet("balance", "[flags] ADDRESS")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("an address is required")
+	}
+	address, err := decodeHex("address", fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	c, err := dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	balance, err := c.Balance(ctx, address)
+	if err != nil {
+		return err
+	}
+	view := map[string]interface{}{"address": hexBytes(address), "balance": balance}
+	return output(view, func() {
+		fmt.Println("Balance:", balance)
+	})
+}
+
+func runTx(args []string) error {
+	fs := newFlagSet("tx", "[flags] ID")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("a transaction id is required")
+	}
+	id, err := decodeHex("transaction id", fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	c, err := dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	txn, err := c.Transaction(ctx, id)
+	if err != nil {
+		return err
+	}
+	return printTransaction(txn)
+}
+
+func runReceipt(args []string) error {
+	fs := newFlagSet("receipt", "[flags] ID")
+	wait := fs.Bool("wait", false, "Wait for the transaction to be executed.")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("a transaction id is required")
+	}
+	id, err := decodeHex("transaction id", fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	c, err := dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var receipt *pb.Receipt
+	if *wait {
+		receipt, err = c.WaitForReceipt(ctx, id)
+	} else {
+		receipt, err = c.Receipt(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+	return printReceipt(receipt)
+}
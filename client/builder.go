@@ -0,0 +1,100 @@
+package client
+
+import (
+	"errors"
+
+	pb "github.com/sporeframework/spore/protocol"
+)
+
+// Builder builds a signed transaction. Fields that are not set are filled in
+// by Client.Send: the nonce from the nonce manager, the parents from the tips
+// of the node and the gas price from the client options.
+type Builder struct {
+	txn         *pb.Transaction
+	hasNonce    bool
+	hasParents  bool
+	hasGasPrice bool
+}
+
+// NewTransaction returns a builder of an empty transaction
+func NewTransaction() *Builder {
+	return &Builder{txn: &pb.Transaction{}}
+}
+
+// To sets the recipient: a contract id to call, an account address to transfer
+// to, or nothing to deploy the data as a contract
+func (b *Builder) To(to []byte) *Builder {
+	b.txn.To = to
+	return b
+}
+
+// Data sets the call data, or the contract code of a deployment
+func (b *Builder) Data(data []byte) *Builder {
+	b.txn.Data = data
+	return b
+}
+
+// Contract marks the transaction as a contract deployment or call
+func (b *Builder) Contract(contract bool) *Builder {
+	b.txn.Contract = contract
+	return b
+}
+
+// Gas sets the gas limit
+func (b *Builder) Gas(gas int64) *Builder {
+	b.txn.Gas = gas
+	return b
+}
+
+// GasPrice sets the price paid per unit of gas
+func (b *Builder) GasPrice(gasPrice int64) *Builder {
+	b.txn.GasPrice = gasPrice
+	b.hasGasPrice = true
+	return b
+}
+
+// Value sets the amount transferred to the recipient
+func (b *Builder) Value(value int64) *Builder {
+	b.txn.Value = value
+	return b
+}
+
+// Nonce sets the nonce
+func (b *Builder) Nonce(nonce int32) *Builder {
+	b.txn.Nonce = nonce
+	b.hasNonce = true
+	return b
+}
+
+// Parents sets the ids of the DAG parents
+func (b *Builder) Parents(parents [][]byte) *Builder {
+	b.txn.Parents = parents
+	b.hasParents = true
+	return b
+}
+
+// Sign returns the transaction sent and signed by the signer, with its id set
+// to the canonical transaction hash
+func (b *Builder) Sign(signer Signer) (*pb.Transaction, error) {
+	if signer == nil {
+		return nil, errors.New("no signer")
+	}
+
+	txn := &pb.Transaction{
+		To:       b.txn.To,
+		From:     signer.Address(),
+		Data:     b.txn.Data,
+		Gas:      b.txn.Gas,
+		GasPrice: b.txn.GasPrice,
+		Nonce:    b.txn.Nonce,
+		Value:    b.txn.Value,
+		Contract: b.txn.Contract,
+		Parents:  b.txn.Parents,
+	}
+	err := signer.Sign(txn)
+	if err != nil {
+		return nil, err
+	}
+	txn.Id = pb.TransactionHash(txn)
+	return txn, nil
+}
@@ -0,0 +1,175 @@
+// Package client is a Go client of the spore RPC interface. It signs
+// transactions canonically, manages account nonces, fails over between node
+// endpoints and wraps the common calls: deploying and calling contracts,
+// queries, receipts and event subscriptions.
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/sporeframework/spore/protocol"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultRetries is how many times a call is retried on another endpoint
+	// when its endpoint is unavailable
+	DefaultRetries = 3
+
+	// DefaultBackoff is the delay before a call is retried
+	DefaultBackoff = 200 * time.Millisecond
+
+	// DefaultPollInterval is how often WaitForReceipt asks for a receipt
+	DefaultPollInterval = 500 * time.Millisecond
+)
+
+// Client is a connection to one or more spore nodes. Calls go to a single
+// endpoint until it becomes unavailable, then fail over to the next endpoint
+// in round-robin order. A Client is safe for concurrent use.
+type Client struct {
+	conns   []*grpc.ClientConn
+	clients []pb.SporeClient
+	current uint32
+	opts    options
+	nonces  *NonceManager
+}
+
+type options struct {
+	tls          *tls.Config
+	retries      int
+	backoff      time.Duration
+	pollInterval time.Duration
+	gasPrice     int64
+	dialOptions  []grpc.DialOption
+}
+
+// Option configures a Client
+type Option func(*options)
+
+// WithTLS connects to the nodes over TLS instead of plaintext
+func WithTLS(config *tls.Config) Option {
+	return func(o *options) { o.tls = config }
+}
+
+// WithRetries sets how many times a call is retried when its endpoint is
+// unavailable
+func WithRetries(retries int) Option {
+	return func(o *options) { o.retries = retries }
+}
+
+// WithBackoff sets the delay before a call is retried
+func WithBackoff(backoff time.Duration) Option {
+	return func(o *options) { o.backoff = backoff }
+}
+
+// WithPollInterval sets how often WaitForReceipt asks for a receipt
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *options) { o.pollInterval = interval }
+}
+
+// WithGasPrice sets the gas price of transactions that do not set one
+func WithGasPrice(gasPrice int64) Option {
+	return func(o *options) { o.gasPrice = gasPrice }
+}
+
+// WithDialOptions adds options to the gRPC connections of the endpoints
+func WithDialOptions(dialOptions ...grpc.DialOption) Option {
+	return func(o *options) { o.dialOptions = append(o.dialOptions, dialOptions...) }
+}
+
+// Dial returns a client of the nodes at the endpoints, given as host:port.
+// Connections are established in the background, so an unreachable endpoint
+// only fails the calls sent to it.
+func Dial(ctx context.Context, endpoints []string, opts ...Option) (*Client, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("no endpoints")
+	}
+
+	c := &Client{
+		opts: options{
+			retries:      DefaultRetries,
+			backoff:      DefaultBackoff,
+			pollInterval: DefaultPollInterval,
+		},
+	}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+
+	dialOptions := []grpc.DialOption{grpc.WithInsecure()}
+	if c.opts.tls != nil {
+		dialOptions = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(c.opts.tls))}
+	}
+	dialOptions = append(dialOptions, c.opts.dialOptions...)
+
+	for _, endpoint := range endpoints {
+		conn, err := grpc.DialContext(ctx, endpoint, dialOptions...)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.conns = append(c.conns, conn)
+		c.clients = append(c.clients, pb.NewSporeClient(conn))
+	}
+
+	c.nonces = NewNonceManager(c)
+	return c, nil
+}
+
+// Close closes the connections to all endpoints
+func (c *Client) Close() error {
+	var err error
+	for _, conn := range c.conns {
+		if cerr := conn.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Nonces returns the nonce manager of the client
+func (c *Client) Nonces() *NonceManager {
+	return c.nonces
+}
+
+// do runs a call against the current endpoint, failing over to the next
+// endpoint and retrying while the endpoint is unavailable
+func (c *Client) do(ctx context.Context, call func(pb.SporeClient) error) error {
+	var err error
+	for attempt := 0; attempt <= c.opts.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(c.opts.backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		i := atomic.LoadUint32(&c.current)
+		err = call(c.clients[i])
+		if !retryable(err) {
+			return err
+		}
+		c.failover(i)
+	}
+	return err
+}
+
+// failover moves from the endpoint i to the next one, unless another call
+// already moved away from it
+func (c *Client) failover(i uint32) {
+	next := (i + 1) % uint32(len(c.clients))
+	atomic.CompareAndSwapUint32(&c.current, i, next)
+}
+
+// retryable reports whether a call failed because its endpoint is unavailable
+func retryable(err error) bool {
+	return err != nil && status.Code(err) == codes.Unavailable
+}
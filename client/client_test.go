@@ -0,0 +1,320 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	pb "github.com/sporeframework/spore/protocol"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeNode is an in-memory spore node
+type fakeNode struct {
+	pb.UnimplementedSporeServer
+
+	mu          sync.Mutex
+	unavailable bool
+	nonce       int32
+	nonceCalls  int
+	sent        []*pb.Transaction
+	reject      error
+	receipts    map[string]*pb.Receipt
+	events      []*pb.Event
+	filters     []*pb.EventFilter
+	streamErr   error
+}
+
+func (n *fakeNode) check() error {
+	if n.unavailable {
+		return status.Error(codes.Unavailable, "node is down")
+	}
+	return nil
+}
+
+func (n *fakeNode) GetAccountNonce(ctx context.Context, in *pb.Account) (*pb.AccountNonce, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := n.check(); err != nil {
+		return nil, err
+	}
+	n.nonceCalls++
+	return &pb.AccountNonce{PendingNonce: n.nonce}, nil
+}
+
+func (n *fakeNode) GetTips(ctx context.Context, in *pb.TipsRequest) (*pb.TipsResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := n.check(); err != nil {
+		return nil, err
+	}
+	return &pb.TipsResponse{Tips: [][]byte{make([]byte, 32)}}, nil
+}
+
+func (n *fakeNode) Send(ctx context.Context, in *pb.Transaction) (*pb.TransactionResponse, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := n.check(); err != nil {
+		return nil, err
+	}
+	if n.reject != nil {
+		return nil, n.reject
+	}
+	n.sent = append(n.sent, in)
+	return &pb.TransactionResponse{TransactionId: in.Id}, nil
+}
+
+func (n *fakeNode) CreateContract(ctx context.Context, in *pb.Transaction) (*pb.TransactionResponse, error) {
+	return n.Send(ctx, in)
+}
+
+func (n *fakeNode) GetReceipt(ctx context.Context, in *pb.TransactionId) (*pb.Receipt, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.reject != nil {
+		return nil, n.reject
+	}
+	receipt, ok := n.receipts[string(in.TransactionId)]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "transaction has not been executed")
+	}
+	return receipt, nil
+}
+
+func (n *fakeNode) SubscribeEvents(filter *pb.EventFilter, stream pb.Spore_SubscribeEventsServer) error {
+	n.mu.Lock()
+	n.filters = append(n.filters, filter)
+	events, streamErr := n.events, n.streamErr
+	n.mu.Unlock()
+
+	for _, event := range events {
+		if filter.Resume && event.Type != pb.Event_TRANSACTION_ADDED && event.Index < filter.Cursor {
+			continue
+		}
+		if err := stream.Send(event); err != nil {
+			return err
+		}
+	}
+	return streamErr
+}
+
+// dialNodes returns a client of in-memory nodes
+func dialNodes(t *testing.T, nodes ...*fakeNode) *Client {
+	listeners := make(map[string]*bufconn.Listener)
+	var endpoints []string
+	for i, node := range nodes {
+		lis := bufconn.Listen(1 << 20)
+		s := grpc.NewServer()
+		pb.RegisterSporeServer(s, node)
+		go s.Serve(lis)
+		t.Cleanup(s.Stop)
+
+		endpoint := string(rune('a' + i))
+		listeners[endpoint] = lis
+		endpoints = append(endpoints, endpoint)
+	}
+
+	dialer := func(ctx context.Context, endpoint string) (net.Conn, error) {
+		return listeners[endpoint].Dial()
+	}
+	c, err := Dial(context.Background(), endpoints,
+		WithDialOptions(grpc.WithContextDialer(dialer)),
+		WithBackoff(time.Millisecond),
+		WithPollInterval(time.Millisecond),
+		WithGasPrice(2))
+	if err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func testSigner(t *testing.T) Signer {
+	prv, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	return NewSigner(prv)
+}
+
+func TestSend(t *testing.T) {
+	node := &fakeNode{nonce: 5}
+	c := dialNodes(t, node)
+	signer := testSigner(t)
+	ctx := context.Background()
+
+	deployID, contractID, err := c.Deploy(ctx, signer, []byte("wasm"), 1000)
+	if err != nil {
+		t.Fatalf("failed to deploy: %s", err)
+	}
+	callID, err := c.Call(ctx, signer, contractID[:], 1000, "increment")
+	if err != nil {
+		t.Fatalf("failed to call: %s", err)
+	}
+
+	if len(node.sent) != 2 {
+		t.Fatalf("expected 2 transactions, got %d", len(node.sent))
+	}
+	for i, id := range [][]byte{deployID, callID} {
+		txn := node.sent[i]
+		if !bytes.Equal(txn.Id, id) || !bytes.Equal(txn.Id, pb.TransactionHash(txn)) {
+			t.Errorf("transaction %d has id %x, expected its hash", i, txn.Id)
+		}
+		if txn.Nonce != int32(5+i) {
+			t.Errorf("transaction %d has nonce %d, expected %d", i, txn.Nonce, 5+i)
+		}
+		if txn.GasPrice != 2 || len(txn.Parents) != 1 {
+			t.Errorf("transaction %d is missing its gas price or parents", i)
+		}
+
+		publicKey, err := crypto.SigToPub(pb.TransactionHash(txn), txn.Signature)
+		if err != nil || !bytes.Equal(crypto.PubkeyToAddress(*publicKey).Bytes(), signer.Address()) {
+			t.Errorf("transaction %d is not signed by its sender", i)
+		}
+	}
+	if node.nonceCalls != 1 {
+		t.Errorf("expected the nonce to be fetched once, got %d", node.nonceCalls)
+	}
+}
+
+func TestSend_ResetNonce(t *testing.T) {
+	node := &fakeNode{reject: errors.New("invalid nonce")}
+	c := dialNodes(t, node)
+	signer := testSigner(t)
+	ctx := context.Background()
+
+	if _, err := c.Transfer(ctx, signer, make([]byte, 20), 1, 100); err == nil {
+		t.Fatal("expected rejected transaction to fail")
+	}
+
+	node.reject = nil
+	if _, err := c.Transfer(ctx, signer, make([]byte, 20), 1, 100); err != nil {
+		t.Fatalf("failed to transfer: %s", err)
+	}
+	if node.nonceCalls != 2 {
+		t.Errorf("expected the nonce to be fetched again after a rejection, got %d fetches", node.nonceCalls)
+	}
+	if node.sent[0].Nonce != 0 {
+		t.Errorf("expected the rejected nonce to be reused, got %d", node.sent[0].Nonce)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	down := &fakeNode{unavailable: true}
+	up := &fakeNode{}
+	c := dialNodes(t, down, up)
+
+	if _, err := c.Transfer(context.Background(), testSigner(t), make([]byte, 20), 1, 100); err != nil {
+		t.Fatalf("expected failover to the available node, got %s", err)
+	}
+	if len(up.sent) != 1 {
+		t.Errorf("expected the transaction to be sent to the available node")
+	}
+
+	up.mu.Lock()
+	up.unavailable = true
+	up.mu.Unlock()
+	if _, err := c.Tips(context.Background()); status.Code(err) != codes.Unavailable {
+		t.Errorf("expected unavailable error when all nodes are down, got %v", err)
+	}
+}
+
+func TestFailover_Nonce(t *testing.T) {
+	first := &fakeNode{nonce: 5}
+	second := &fakeNode{nonce: 9}
+	c := dialNodes(t, first, second)
+	signer := testSigner(t)
+	ctx := context.Background()
+
+	if _, err := c.Transfer(ctx, signer, make([]byte, 20), 1, 100); err != nil {
+		t.Fatalf("failed to transfer: %s", err)
+	}
+	first.mu.Lock()
+	first.unavailable = true
+	first.mu.Unlock()
+	if _, err := c.Transfer(ctx, signer, make([]byte, 20), 1, 100); err != nil {
+		t.Fatalf("expected failover to the available node, got %s", err)
+	}
+
+	// nonces counted against the first node do not apply to the second one
+	if len(second.sent) != 1 || second.sent[0].Nonce != 9 {
+		t.Errorf("expected the pending nonce of the second node to be used, got %v", second.sent)
+	}
+}
+
+func TestWaitForReceipt(t *testing.T) {
+	node := &fakeNode{receipts: make(map[string]*pb.Receipt)}
+	c := dialNodes(t, node)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		node.mu.Lock()
+		node.receipts["id"] = &pb.Receipt{TransactionId: []byte("id"), Status: pb.Receipt_SUCCESS}
+		node.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	receipt, err := c.WaitForReceipt(ctx, []byte("id"))
+	if err != nil || receipt.Status != pb.Receipt_SUCCESS {
+		t.Fatalf("expected successful receipt, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.WaitForReceipt(ctx, []byte("missing")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	// other errors are not retried
+	node.mu.Lock()
+	node.reject = status.Error(codes.PermissionDenied, "receipts are private")
+	node.mu.Unlock()
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.WaitForReceipt(ctx, []byte("missing")); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected permission denied, got %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Error("expected the error to be returned without waiting for the deadline")
+	}
+}
+
+func TestSubscribe_Resume(t *testing.T) {
+	executed := func(index uint64, topic string) *pb.Event {
+		return &pb.Event{Type: pb.Event_CONTRACT_EVENT, Index: index, Topic: []byte(topic)}
+	}
+
+	// the first node breaks in the middle of the events of index 2
+	first := &fakeNode{
+		events:    []*pb.Event{executed(1, "a"), executed(2, "b")},
+		streamErr: status.Error(codes.Unavailable, "node is down"),
+	}
+	second := &fakeNode{
+		events: []*pb.Event{executed(1, "a"), executed(2, "b"), executed(2, "c"), executed(3, "d")},
+	}
+	c := dialNodes(t, first, second)
+
+	var topics []byte
+	err := c.Subscribe(context.Background(), &pb.EventFilter{}, func(event *pb.Event) error {
+		topics = append(topics, event.Topic...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %s", err)
+	}
+	if string(topics) != "abcd" {
+		t.Errorf("expected each event once and in order, got %s", topics)
+	}
+	if len(second.filters) != 1 || !second.filters[0].Resume || second.filters[0].Cursor != 2 {
+		t.Errorf("expected resubscription from index 2, got %v", second.filters)
+	}
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/sporeframework/spore/protocol"
+	"google.golang.org/protobuf/proto"
+)
+
+// Subscribe streams the events matching the filter to handle until the
+// context is done, handle returns an error, or the endpoints stay unavailable
+// for all retries.
+//
+// When the stream breaks, Subscribe resubscribes on the next endpoint and
+// resumes the events of executed transactions right after the last one
+// handled, so none of them are missed or handled twice. TRANSACTION_ADDED
+// events are not stored, and those sent while resubscribing are missed.
+func (c *Client) Subscribe(ctx context.Context, filter *pb.EventFilter, handle func(*pb.Event) error) error {
+	filter = proto.Clone(filter).(*pb.EventFilter)
+
+	// resuming replays the events of the last execution sequence number seen,
+	// of which the first skip were already handled
+	seen := false
+	var index uint64
+	skip := 0
+
+	failures := 0
+	for {
+		var stream pb.Spore_SubscribeEventsClient
+		err := c.do(ctx, func(sc pb.SporeClient) (err error) {
+			stream, err = sc.SubscribeEvents(ctx, filter)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		replayed := 0
+		for {
+			var event *pb.Event
+			event, err = stream.Recv()
+			if err != nil {
+				break
+			}
+			failures = 0
+
+			if event.Type == pb.Event_TRANSACTION_ADDED {
+				if err = handle(event); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if seen && event.Index == index && replayed < skip {
+				replayed++
+				continue
+			}
+			if seen && event.Index == index {
+				skip++
+			} else {
+				seen, index, skip = true, event.Index, 1
+			}
+			replayed = skip
+
+			if err = handle(event); err != nil {
+				return err
+			}
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == io.EOF {
+			return nil
+		}
+
+		failures++
+		if failures > c.opts.retries {
+			return err
+		}
+		c.failover(atomic.LoadUint32(&c.current))
+		select {
+		case <-time.After(c.opts.backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if seen {
+			filter.Resume = true
+			filter.Cursor = index
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+
+	pb "github.com/sporeframework/spore/protocol"
+)
+
+// Order returns up to limit transaction ids of the DAG order from the order
+// index start, and the size of the order. A limit of 0 returns all of them.
+func (c *Client) Order(ctx context.Context, start uint64, limit uint32) ([][]byte, uint64, error) {
+	var resp *pb.OrderResponse
+	err := c.do(ctx, func(sc pb.SporeClient) (err error) {
+		resp, err = sc.GetOrder(ctx, &pb.OrderRequest{Start: start, Limit: limit})
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.GetTransactions(), resp.GetSize(), nil
+}
+
+// DagNode returns the position of a transaction in the DAG
+func (c *Client) DagNode(ctx context.Context, id []byte) (*pb.DagNode, error) {
+	var node *pb.DagNode
+	err := c.do(ctx, func(sc pb.SporeClient) (err error) {
+		node, err = sc.GetDagNode(ctx, &pb.TransactionId{TransactionId: id})
+		return err
+	})
+	return node, err
+}
+
+// Status returns the status of the node
+func (c *Client) Status(ctx context.Context) (*pb.Status, error) {
+	var status *pb.Status
+	err := c.do(ctx, func(sc pb.SporeClient) (err error) {
+		status, err = sc.GetStatus(ctx, &pb.StatusRequest{})
+		return err
+	})
+	return status, err
+}
+
+// Peers returns the peers the node is connected to
+func (c *Client) Peers(ctx context.Context) ([]*pb.Peer, error) {
+	var resp *pb.PeersResponse
+	err := c.do(ctx, func(sc pb.SporeClient) (err error) {
+		resp, err = sc.GetPeers(ctx, &pb.PeersRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetPeers(), nil
+}
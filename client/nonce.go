@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	pb "github.com/sporeframework/spore/protocol"
+)
+
+// NonceManager hands out consecutive nonces of accounts. The first nonce of an
+// account is the pending nonce of the node, later ones are counted locally so
+// that transactions can be sent without waiting for the previous ones. Pending
+// nonces are local to a node, so the counted nonces are forgotten when the
+// client fails over to another endpoint.
+type NonceManager struct {
+	mu       sync.Mutex
+	client   *Client
+	nonces   map[string]int32
+	endpoint uint32
+}
+
+// NewNonceManager returns a nonce manager that fetches nonces from the client
+func NewNonceManager(c *Client) *NonceManager {
+	return &NonceManager{
+		client: c,
+		nonces: make(map[string]int32),
+	}
+}
+
+// Next reserves and returns the next nonce of the account
+func (m *NonceManager) Next(ctx context.Context, address []byte) (int32, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.checkEndpoint()
+	nonce, ok := m.nonces[string(address)]
+	if !ok {
+		var resp *pb.AccountNonce
+		err := m.client.do(ctx, func(c pb.SporeClient) (err error) {
+			resp, err = c.GetAccountNonce(ctx, &pb.Account{Address: address})
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+		nonce = resp.GetPendingNonce()
+		m.checkEndpoint()
+	}
+
+	m.nonces[string(address)] = nonce + 1
+	return nonce, nil
+}
+
+// checkEndpoint forgets the counted nonces if the client failed over since
+// they were fetched. The caller must hold mu.
+func (m *NonceManager) checkEndpoint() {
+	if endpoint := atomic.LoadUint32(&m.client.current); endpoint != m.endpoint {
+		m.nonces = make(map[string]int32)
+		m.endpoint = endpoint
+	}
+}
+
+// Reset forgets the nonces of the account, so that the next one is fetched
+// from the node again. It is called when a transaction is rejected.
+func (m *NonceManager) Reset(address []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.nonces, string(address))
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	pb "github.com/sporeframework/spore/protocol"
+)
+
+// Signer signs transactions on behalf of an account
+type Signer interface {
+	// Address returns the address of the account
+	Address() []byte
+
+	// Sign sets the signature scheme, public key and signature of a
+	// transaction sent by the account
+	Sign(txn *pb.Transaction) error
+}
+
+type secp256k1Signer struct {
+	prv     *ecdsa.PrivateKey
+	address []byte
+}
+
+// NewSigner returns a signer of secp256k1 signatures of the transaction hash,
+// for the account of the Ethereum address of the key
+func NewSigner(prv *ecdsa.PrivateKey) Signer {
+	return &secp256k1Signer{
+		prv:     prv,
+		address: crypto.PubkeyToAddress(prv.PublicKey).Bytes(),
+	}
+}
+
+func (s *secp256k1Signer) Address() []byte {
+	return s.address
+}
+
+func (s *secp256k1Signer) Sign(txn *pb.Transaction) error {
+	sig, err := crypto.Sign(pb.TransactionHash(txn), s.prv)
+	if err != nil {
+		return err
+	}
+	txn.Scheme = pb.SignatureScheme_SECP256K1
+	txn.PublicKey = nil
+	txn.Signature = sig
+	return nil
+}
+
+type ed25519Signer struct {
+	prv ed25519.PrivateKey
+}
+
+// NewEd25519Signer returns a signer of ed25519 signatures of the transaction
+// hash, for the account of the address of the public key
+func NewEd25519Signer(prv ed25519.PrivateKey) Signer {
+	return &ed25519Signer{prv: prv}
+}
+
+func (s *ed25519Signer) Address() []byte {
+	return pb.Ed25519Address(s.prv.Public().(ed25519.PublicKey))
+}
+
+func (s *ed25519Signer) Sign(txn *pb.Transaction) error {
+	txn.Scheme = pb.SignatureScheme_ED25519
+	txn.PublicKey = s.prv.Public().(ed25519.PublicKey)
+	txn.Signature = ed25519.Sign(s.prv, pb.TransactionHash(txn))
+	return nil
+}
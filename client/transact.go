@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/sporeframework/spore/contract"
+	pb "github.com/sporeframework/spore/protocol"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Send signs the transaction of the builder and sends it, returning its id.
+// The nonce, parents and gas price are filled in if the builder does not set
+// them. A send that failed over is safe to retry: nodes report a transaction
+// they already know as sent.
+func (c *Client) Send(ctx context.Context, b *Builder, signer Signer) ([]byte, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("no signer")
+	}
+
+	filled := &Builder{
+		txn:         proto.Clone(b.txn).(*pb.Transaction),
+		hasNonce:    b.hasNonce,
+		hasParents:  b.hasParents,
+		hasGasPrice: b.hasGasPrice,
+	}
+	if !filled.hasGasPrice {
+		filled.GasPrice(c.opts.gasPrice)
+	}
+	if !filled.hasParents {
+		tips, err := c.Tips(ctx)
+		if err != nil {
+			return nil, err
+		}
+		filled.Parents(tips)
+	}
+	if !filled.hasNonce {
+		nonce, err := c.nonces.Next(ctx, signer.Address())
+		if err != nil {
+			return nil, err
+		}
+		filled.Nonce(nonce)
+	}
+
+	txn, err := filled.Sign(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *pb.TransactionResponse
+	err = c.do(ctx, func(sc pb.SporeClient) (err error) {
+		if len(txn.To) == 0 {
+			resp, err = sc.CreateContract(ctx, txn)
+		} else {
+			resp, err = sc.Send(ctx, txn)
+		}
+		return err
+	})
+	if err != nil {
+		// the reserved nonce may not have been used
+		if !b.hasNonce {
+			c.nonces.Reset(signer.Address())
+		}
+		return nil, err
+	}
+	return resp.GetTransactionId(), nil
+}
+
+// Deploy deploys a contract, returning the id of the transaction and the id of
+// the contract
+func (c *Client) Deploy(ctx context.Context, signer Signer, wasm []byte, gas int64) ([]byte, [32]byte, error) {
+	txnID, err := c.Send(ctx, NewTransaction().Data(wasm).Contract(true).Gas(gas), signer)
+	if err != nil {
+		return nil, [32]byte{}, err
+	}
+	return txnID, sha256.Sum256(wasm), nil
+}
+
+// Call calls a function of a contract, returning the id of the transaction
+func (c *Client) Call(ctx context.Context, signer Signer, contractID []byte, gas int64, name string, args ...[]byte) ([]byte, error) {
+	data := contract.EncodeCall(name, args...)
+	return c.Send(ctx, NewTransaction().To(contractID).Data(data).Contract(true).Gas(gas), signer)
+}
+
+// Transfer transfers value to an account, returning the id of the transaction
+func (c *Client) Transfer(ctx context.Context, signer Signer, to []byte, value int64, gas int64) ([]byte, error) {
+	return c.Send(ctx, NewTransaction().To(to).Value(value).Gas(gas), signer)
+}
+
+// Query calls a function of a contract without sending a transaction, and
+// returns its result and the gas it used
+func (c *Client) Query(ctx context.Context, contractID []byte, from []byte, gas int64, name string, args ...[]byte) ([]byte, int64, error) {
+	req := &pb.QueryRequest{
+		Contract: contractID,
+		Data:     contract.EncodeCall(name, args...),
+		Gas:      gas,
+		From:     from,
+	}
+
+	var resp *pb.QueryResponse
+	err := c.do(ctx, func(sc pb.SporeClient) (err error) {
+		resp, err = sc.Query(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.GetResult(), resp.GetGasUsed(), nil
+}
+
+// Tips returns the ids of the current tips of the DAG
+func (c *Client) Tips(ctx context.Context) ([][]byte, error) {
+	var resp *pb.TipsResponse
+	err := c.do(ctx, func(sc pb.SporeClient) (err error) {
+		resp, err = sc.GetTips(ctx, &pb.TipsRequest{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetTips(), nil
+}
+
+// Balance returns the balance of an account
+func (c *Client) Balance(ctx context.Context, address []byte) (int64, error) {
+	var resp *pb.Balance
+	err := c.do(ctx, func(sc pb.SporeClient) (err error) {
+		resp, err = sc.GetBalance(ctx, &pb.Account{Address: address})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return resp.GetBalance(), nil
+}
+
+// Transaction returns a transaction by id
+func (c *Client) Transaction(ctx context.Context, id []byte) (*pb.Transaction, error) {
+	var txn *pb.Transaction
+	err := c.do(ctx, func(sc pb.SporeClient) (err error) {
+		txn, err = sc.GetTransaction(ctx, &pb.TransactionId{TransactionId: id})
+		return err
+	})
+	return txn, err
+}
+
+// Receipt returns the receipt of an executed transaction
+func (c *Client) Receipt(ctx context.Context, id []byte) (*pb.Receipt, error) {
+	var receipt *pb.Receipt
+	err := c.do(ctx, func(sc pb.SporeClient) (err error) {
+		receipt, err = sc.GetReceipt(ctx, &pb.TransactionId{TransactionId: id})
+		return err
+	})
+	return receipt, err
+}
+
+// WaitForReceipt waits until a transaction is executed and returns its
+// receipt. It polls while the transaction is not executed yet or the nodes
+// are unavailable, and gives up when the context is done. Any other error is
+// returned at once.
+func (c *Client) WaitForReceipt(ctx context.Context, id []byte) (*pb.Receipt, error) {
+	ticker := time.NewTicker(c.opts.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := c.Receipt(ctx, id)
+		if err == nil {
+			return receipt, nil
+		}
+		// a call cut short by the context ends the wait below
+		if code := status.Code(err); code != codes.NotFound && code != codes.Unavailable && ctx.Err() == nil {
+			return nil, err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("no receipt of transaction %x: %w (last error: %s)", id, ctx.Err(), err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sporeframework/spore/config"
+	"github.com/sporeframework/spore/datadir"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// bootstrappers
+type arrayFlags []string
+
+func (i *arrayFlags) String() string {
+	return strings.Join(*i, ",")
+}
+func (i *arrayFlags) Set(value string) error {
+	*i = append(*i, value)
+	return nil
+}
+
+// configFlags are the flags overriding settings of the configuration
+type configFlags struct {
+	datadir       *string
+	file          *string
+	bootstrappers arrayFlags
+	listenHost    *string
+	port          *int
+	rpcPort       *int
+	confirmations *int
+	mempoolSize   *int
+	minGasPrice   *int64
+	dbPath        *string
+	logLevel      *string
+}
+
+func addConfigFlags(fs *flag.FlagSet) *configFlags {
+	defaults := config.Default()
+	f := &configFlags{}
+	f.datadir = fs.String("datadir", "", "The data directory holding the configuration, keystore and database, $"+datadir.Env+" or "+datadir.Resolve("")+" if not set.")
+	f.file = fs.String("config", "", "The configuration file, $"+config.FileEnv+" or "+config.FileName+" in the data directory if not set.")
+	fs.Var(&f.bootstrappers, "connect", "Connect to target bootstrap node. This can be any chat node on the network.")
+	f.listenHost = fs.String("host", defaults.Network.ListenHost, "The bootstrap node host listen address")
+	f.port = fs.Int("port", defaults.Network.Port, "The node's listening port. This is useful if using this node as a bootstrapper.")
+	f.rpcPort = fs.Int("rpc", defaults.RPC.Port, "The node's rpc port.")
+	f.confirmations = fs.Int("confirmations", defaults.DAG.ConfirmationDepth, "The blue score depth below the DAG's coloring tip at which transactions are executed.")
+	f.mempoolSize = fs.Int("mempool-size", defaults.Mempool.Size, "The most pending transactions held in the mempool.")
+	f.minGasPrice = fs.Int64("min-gas-price", defaults.RPC.MinGasPrice, "The lowest gas price of transactions accepted over RPC.")
+	f.dbPath = fs.String("db", defaults.Storage.Path, "The directory of the database, relative to the data directory.")
+	f.logLevel = fs.String("log-level", defaults.Log.Level, "The lowest severity logged: trace, debug, info, warning, error or fatal.")
+	return f
+}
+
+// home returns the data directory of the node
+func (f *configFlags) home() string {
+	return datadir.Resolve(*f.datadir)
+}
+
+// load returns the configuration, with the flags set on the command line
+// overriding the file and the environment
+func (f *configFlags) load(fs *flag.FlagSet) (*config.Config, error) {
+	conf, err := config.Load(f.home(), *f.file)
+	if err != nil {
+		return nil, err
+	}
+
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "connect":
+			conf.Network.Bootstrappers = f.bootstrappers
+		case "host":
+			conf.Network.ListenHost = *f.listenHost
+		case "port":
+			conf.Network.Port = *f.port
+		case "rpc":
+			conf.RPC.Port = *f.rpcPort
+		case "confirmations":
+			conf.DAG.ConfirmationDepth = *f.confirmations
+		case "mempool-size":
+			conf.Mempool.Size = *f.mempoolSize
+		case "min-gas-price":
+			conf.RPC.MinGasPrice = *f.minGasPrice
+		case "db":
+			conf.Storage.Path = *f.dbPath
+		case "log-level":
+			conf.Log.Level = *f.logLevel
+		}
+	})
+	return conf, nil
+}
+
+// applyConfig sets up logging from the configuration, with a relative log
+// file under the data directory home
+func applyConfig(home string, conf *config.Config) error {
+	level, err := log.ParseLevel(conf.Log.Level)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(level)
+	if conf.Log.Format == "json" {
+		log.SetFormatter(&log.JSONFormatter{})
+	}
+	switch conf.Log.Output {
+	case "stdout":
+		log.SetOutput(os.Stdout)
+	case "stderr":
+		log.SetOutput(os.Stderr)
+	default:
+		file, err := os.OpenFile(datadir.Path(home, conf.Log.Output), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		log.SetOutput(file)
+	}
+
+	return nil
+}
+
+// runConfig runs the config command, which shows or validates the
+// configuration resolved from the file, the environment and the flags
+func runConfig(args []string) {
+	if len(args) == 0 || (args[0] != "show" && args[0] != "validate") {
+		fmt.Fprintln(os.Stderr, "usage: spore config show|validate [flags]")
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("config "+args[0], flag.ExitOnError)
+	flags := addConfigFlags(fs)
+	fs.Parse(args[1:])
+
+	conf, err := flags.load(fs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %s\n", err)
+		os.Exit(1)
+	}
+
+	if args[0] == "show" {
+		out, err := conf.JSON()
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(out))
+	}
+
+	if err = conf.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %s\n", err)
+		os.Exit(1)
+	}
+	if args[0] == "validate" {
+		fmt.Println("✅ Configuration is valid")
+	}
+}
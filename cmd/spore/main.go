@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/sporeframework/spore"
+	contract "github.com/sporeframework/spore/contract"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func main2() {
+	contract.WasmTime()
+	// Gasm()
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		runConfig(os.Args[2:])
+		return
+	}
+
+	// parse the flags overriding the configuration, then the node's own flags
+	flags := addConfigFlags(flag.CommandLine)
+	useKey := flag.Bool("use-key", false, "Use an ECSDS keypair as this node's identifier. The keypair is generated if it does not exist in the keystore of the data directory.")
+	passwordFile := flag.String("password-file", "", "File with the passphrase of the node identity. The passphrase is read from $SPORE_PASSPHRASE or prompted for if not set.")
+	info := flag.Bool("info", false, "Display node endpoint information before logging into the main chat room")
+	daemon := flag.Bool("daemon", false, "Run as a bootstrap daemon only")
+	shutdownTimeout := flag.Duration("shutdown-timeout", spore.ShutdownTimeout, "How long to wait for RPC calls in progress when shutting down.")
+	flag.Parse()
+
+	home, conf := ConfigSetup(flags)
+
+	opts := []spore.Option{spore.WithHome(home)}
+	if *useKey {
+		opts = append(opts, spore.WithIdentity(GetKey(home, *passwordFile)))
+	}
+	node, err := spore.New(conf, opts...)
+	if err != nil {
+		fatal(err)
+	}
+	if err = node.Start(context.Background()); err != nil {
+		fatal(err)
+	}
+	h := node.Host()
+	if *useKey {
+		fmt.Println("🔐 Using identity from key:", h.ID().Pretty())
+	}
+
+	donec := make(chan struct{}, 1)
+	//go chatInputLoop(ctx, h, ps, donec)
+
+	// SIGTERM is what docker sends to stop a container
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	if *info {
+		fmt.Println("🔖  Network id:", conf.Network.ClusterKey)
+		fmt.Print("👢 Available endpoints: \n")
+		for _, addr := range h.Addrs() {
+			fmt.Printf("	%s/p2p/%s\n", addr, h.ID().Pretty())
+			log.Infof("	%s/p2p/%s", addr, h.ID().Pretty())
+		}
+		fmt.Println("Press any key to continue...")
+		fmt.Scanln() // wait for Enter Key
+	}
+
+	if *daemon {
+		// select {}
+		// TODO remove this
+		select {
+		case <-stop:
+		case <-donec:
+		}
+	} else {
+
+		select {
+		case <-stop:
+		case <-donec:
+		}
+		// draw the UI
+		/*
+			ui := NewChatUI(cr)
+			if err = ui.Run(); err != nil {
+				printErr("error running text UI: %s", err)
+				log.Error("error running text UI: %s", err)
+			}
+		*/
+	}
+	shutdown(node, stop, *shutdownTimeout)
+}
+
+// shutdown stops the node, waiting at most timeout for RPC calls in progress.
+// A second signal exits right away.
+func shutdown(node *spore.Node, stop chan os.Signal, timeout time.Duration) {
+	fmt.Println("🛑 Shutting down...")
+	go func() {
+		<-stop
+		fmt.Fprintln(os.Stderr, "❌ Interrupted during shutdown")
+		os.Exit(1)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := node.Shutdown(ctx); err != nil {
+		fatal(err)
+	}
+	fmt.Println("👋 Node stopped")
+}
+
+// printErr is like fmt.Printf, but writes to stderr.
+func printErr(m string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, m, args...)
+}
+
+// defaultNick generates a nickname based on the $USER environment variable and
+// the last 8 chars of a peer ID.
+func defaultNick(p peer.ID) string {
+	return fmt.Sprintf("%s-%s", os.Getenv("USER"), shortID(p))
+}
+
+// shortID returns the last 8 chars of a base58-encoded peer id.
+func shortID(p peer.ID) string {
+	pretty := p.Pretty()
+	return pretty[len(pretty)-8:]
+}
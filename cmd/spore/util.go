@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	crypto "github.com/libp2p/go-libp2p-crypto"
+	"github.com/sporeframework/spore/config"
+	"github.com/sporeframework/spore/keystore"
+)
+
+// ConfigSetup loads the configuration of the node and applies it. An invalid
+// configuration stops the node.
+func ConfigSetup(flags *configFlags) (string, *config.Config) {
+	home := flags.home()
+	fmt.Println("📁 Data directory:", home)
+
+	conf, err := flags.load(flag.CommandLine)
+	if err == nil {
+		err = conf.Validate()
+	}
+	if err == nil {
+		err = applyConfig(home, conf)
+	}
+	if err != nil {
+		fatal(err)
+	}
+	return home, conf
+}
+
+// fatal prints err and stops the node
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "❌ %s\n", err)
+	os.Exit(1)
+}
+
+// GetKey unlocks the libp2p identity of the node from the keystore in the
+// data directory. A plaintext key written by previous versions is encrypted
+// into the keystore on first use. A keystore that cannot be unlocked stops the
+// node.
+func GetKey(home, passwordFile string) crypto.PrivKey {
+	ks, err := keystore.New(filepath.Join(home, "keystore"))
+	if err != nil {
+		fatal(err)
+	}
+
+	prv, err := keystore.UnlockNodeIdentity(ks, passwordFile, filepath.Join(home, ".key"))
+	if err != nil {
+		fatal(err)
+	}
+	return prv
+}
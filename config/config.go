@@ -0,0 +1,257 @@
+// Package config holds the configuration of a spore node. A configuration is
+// resolved from the defaults, then the JSON configuration file, then the
+// SPORE_* environment variables, each overriding the previous one. Command-line
+// flags are applied last by the node.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultClusterKey is the secret of the public spore network
+const DefaultClusterKey = "f73792a8ba5fa5306039ccd82f79887b3319457752ff0b604fc736c72134e336"
+
+// FileEnv is the environment variable naming the configuration file, used
+// when no file is given on the command line
+const FileEnv = "SPORE_CONFIG"
+
+// FileName is the name of the configuration file in the data directory
+const FileName = "conf.json"
+
+// DefaultBootstrappers are the bootstrap nodes of the public spore network
+var DefaultBootstrappers = []string{
+	"/ip4/35.224.203.143/tcp/4001/p2p/QmfNdsi6tQfuQ1AbiVbTwxziaRCzuamjP711y42mNW33DS",
+}
+
+// Config is the configuration of a node
+type Config struct {
+	Network   NetworkConfig   `json:"network"`
+	RPC       RPCConfig       `json:"rpc"`
+	DAG       DAGConfig       `json:"dag"`
+	Mempool   MempoolConfig   `json:"mempool"`
+	Storage   StorageConfig   `json:"storage"`
+	Contracts ContractsConfig `json:"contracts"`
+	Log       LogConfig       `json:"log"`
+
+	// Genesis maps hex-encoded account addresses to their initial balances
+	Genesis map[string]int64 `json:"genesis,omitempty"`
+
+	// ClusterKey and Bootstrappers are the top-level fields of configuration
+	// files written by previous versions, moved into Network on load
+	LegacyClusterKey    *string  `json:"ClusterKey,omitempty"`
+	LegacyBootstrappers []string `json:"Bootstrappers,omitempty"`
+}
+
+// NetworkConfig configures the libp2p host
+type NetworkConfig struct {
+	// ListenHost and Port are the address the host listens on, a random port
+	// if Port is 0
+	ListenHost string `json:"listenHost"`
+	Port       int    `json:"port"`
+
+	// ClusterKey is the hex-encoded 32-byte secret of the private network, an
+	// unprotected network if empty
+	ClusterKey    string   `json:"clusterKey"`
+	Bootstrappers []string `json:"bootstrappers"`
+
+	ConnMgr ConnMgrConfig `json:"connMgr"`
+
+	// MdnsInterval is how often the mDNS records of the node are published,
+	// mDNS discovery is disabled if 0
+	MdnsInterval Duration `json:"mdnsInterval"`
+}
+
+// ConnMgrConfig configures the connection manager, which trims connections
+// down to LowWater once there are more than HighWater
+type ConnMgrConfig struct {
+	LowWater    int      `json:"lowWater"`
+	HighWater   int      `json:"highWater"`
+	GracePeriod Duration `json:"gracePeriod"`
+}
+
+// RPCConfig configures the gRPC interface
+type RPCConfig struct {
+	// Port is the TCP port of the gRPC server, a random port if 0
+	Port         int   `json:"port"`
+	MinGasPrice  int64 `json:"minGasPrice"`
+	MaxBatchSize int   `json:"maxBatchSize"`
+}
+
+// DAGConfig configures the ordering and execution of the DAG
+type DAGConfig struct {
+	// K is the PHANTOM parameter of the coloring of the DAG
+	K int `json:"k"`
+
+	// ConfirmationDepth is the blue score depth below the coloring tip at
+	// which transactions are executed
+	ConfirmationDepth int `json:"confirmationDepth"`
+}
+
+// MempoolConfig configures the pool of pending transactions
+type MempoolConfig struct {
+	Size      int      `json:"size"`
+	BatchSize int      `json:"batchSize"`
+	Interval  Duration `json:"interval"`
+}
+
+// StorageConfig configures the database
+type StorageConfig struct {
+	// Path is the directory of the database, relative to the data directory
+	// unless it is absolute
+	Path string `json:"path"`
+}
+
+// ContractsConfig configures the contract engine
+type ContractsConfig struct {
+	QueryGasLimit int64 `json:"queryGasLimit"`
+	MaxWasmSize   int   `json:"maxWasmSize"`
+	MaxDataSize   int   `json:"maxDataSize"`
+}
+
+// LogConfig configures logging
+type LogConfig struct {
+	// Level is a logrus level: trace, debug, info, warning, error or fatal
+	Level string `json:"level"`
+
+	// Format is text or json
+	Format string `json:"format"`
+
+	// Output is stdout, stderr or the path of a file, relative to the data
+	// directory unless it is absolute
+	Output string `json:"output"`
+}
+
+// Duration is a time.Duration written as a string such as "1m30s"
+type Duration struct {
+	time.Duration
+}
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"1m30s\": %s", err)
+	}
+	return d.Set(s)
+}
+
+// Set parses a duration such as "1m30s"
+func (d *Duration) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+// Default returns the default configuration
+func Default() *Config {
+	return &Config{
+		Network: NetworkConfig{
+			ListenHost:    "0.0.0.0",
+			Port:          0,
+			ClusterKey:    DefaultClusterKey,
+			Bootstrappers: append([]string(nil), DefaultBootstrappers...),
+			ConnMgr: ConnMgrConfig{
+				LowWater:    100,
+				HighWater:   400,
+				GracePeriod: Duration{time.Minute},
+			},
+			MdnsInterval: Duration{time.Hour},
+		},
+		RPC: RPCConfig{
+			Port:         9000,
+			MinGasPrice:  0,
+			MaxBatchSize: 4096,
+		},
+		DAG: DAGConfig{
+			K:                 1621,
+			ConfirmationDepth: 6,
+		},
+		Mempool: MempoolConfig{
+			Size:      4096,
+			BatchSize: 100,
+			Interval:  Duration{100 * time.Millisecond},
+		},
+		Storage: StorageConfig{
+			Path: "db",
+		},
+		Contracts: ContractsConfig{
+			QueryGasLimit: 10000000,
+			MaxWasmSize:   512 << 10,
+			MaxDataSize:   64 << 10,
+		},
+		Log: LogConfig{
+			Level:  "warning",
+			Format: "text",
+			Output: "stdout",
+		},
+	}
+}
+
+// Load returns the defaults overridden by the configuration file, then by the
+// environment. A missing file is only an error if it was named explicitly,
+// by file or by SPORE_CONFIG; an empty file uses the conf.json of the data
+// directory home if it exists.
+func Load(home, file string) (*Config, error) {
+	if file == "" {
+		file = os.Getenv(FileEnv)
+	}
+	required := file != ""
+	if file == "" {
+		file = filepath.Join(home, FileName)
+	}
+
+	conf := Default()
+	data, err := ioutil.ReadFile(file)
+	switch {
+	case err == nil:
+		if err = conf.decode(data); err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+	case !os.IsNotExist(err) || required:
+		return nil, err
+	}
+
+	if err = conf.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// decode overrides the configuration with a JSON document. Unknown fields are
+// rejected, so that a misspelled setting is not silently ignored.
+func (c *Config) decode(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return err
+	}
+
+	if c.LegacyClusterKey != nil {
+		c.Network.ClusterKey = *c.LegacyClusterKey
+	}
+	if c.LegacyBootstrappers != nil {
+		c.Network.Bootstrappers = c.LegacyBootstrappers
+	}
+	c.LegacyClusterKey, c.LegacyBootstrappers = nil, nil
+	return nil
+}
+
+// JSON returns the configuration as an indented JSON document, in the format
+// of the configuration file
+func (c *Config) JSON() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
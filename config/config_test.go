@@ -0,0 +1,118 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatalf("failed to create directory: %s", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	file := filepath.Join(dir, "conf.json")
+	if err = ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %s", err)
+	}
+	return file
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	file := writeConfig(t, `{
+		"rpc": {"port": 9001, "minGasPrice": 2},
+		"mempool": {"interval": "1s"},
+		"network": {"bootstrappers": []}
+	}`)
+	os.Setenv("SPORE_RPC_PORT", "9002")
+	os.Setenv("SPORE_NETWORK_CONNMGR_LOW_WATER", "10")
+	os.Setenv("SPORE_NETWORK_BOOTSTRAPPERS", "/ip4/127.0.0.1/tcp/4001/p2p/QmfNdsi6tQfuQ1AbiVbTwxziaRCzuamjP711y42mNW33DS, ")
+	defer os.Unsetenv("SPORE_RPC_PORT")
+	defer os.Unsetenv("SPORE_NETWORK_CONNMGR_LOW_WATER")
+	defer os.Unsetenv("SPORE_NETWORK_BOOTSTRAPPERS")
+
+	conf, err := Load(filepath.Dir(file), "")
+	if err != nil {
+		t.Fatalf("failed to load config: %s", err)
+	}
+
+	// the environment overrides the file, which overrides the defaults
+	if conf.RPC.Port != 9002 || conf.RPC.MinGasPrice != 2 || conf.RPC.MaxBatchSize != 4096 {
+		t.Errorf("unexpected rpc settings %+v", conf.RPC)
+	}
+	if conf.Mempool.Interval.Duration != time.Second || conf.Mempool.Size != 4096 {
+		t.Errorf("unexpected mempool settings %+v", conf.Mempool)
+	}
+	if conf.Network.ConnMgr.LowWater != 10 || len(conf.Network.Bootstrappers) != 1 {
+		t.Errorf("unexpected network settings %+v", conf.Network)
+	}
+	if err = conf.Validate(); err != nil {
+		t.Errorf("expected valid config, got %s", err)
+	}
+
+	if _, err = Load("", file+".missing"); err == nil {
+		t.Error("expected a missing named file to fail")
+	}
+}
+
+func TestLoad_Legacy(t *testing.T) {
+	file := writeConfig(t, `{
+		"ClusterKey": "",
+		"Bootstrappers": [],
+		"Genesis": {"0x00000000000000000000000000000000000000aa": 100}
+	}`)
+
+	conf, err := Load("", file)
+	if err != nil {
+		t.Fatalf("failed to load config: %s", err)
+	}
+	if conf.Network.ClusterKey != "" || len(conf.Network.Bootstrappers) != 0 || conf.Genesis["0x00000000000000000000000000000000000000aa"] != 100 {
+		t.Errorf("expected legacy settings to be moved, got %+v", conf)
+	}
+
+	out, _ := conf.JSON()
+	if strings.Contains(string(out), "ClusterKey") {
+		t.Errorf("expected legacy fields not to be written, got %s", out)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	if _, err := Load("", writeConfig(t, `{"rpc": {"prot": 9001}}`)); err == nil {
+		t.Error("expected unknown field to be rejected")
+	}
+	if _, err := Load("", writeConfig(t, `{"mempool": {"interval": 100}}`)); err == nil {
+		t.Error("expected numeric duration to be rejected")
+	}
+
+	conf := Default()
+	conf.RPC.Port = -1
+	conf.DAG.K = 0
+	conf.Log.Level = "loud"
+	conf.Network.Bootstrappers = []string{"/ip4/127.0.0.1/tcp/4001"}
+	err := conf.Validate()
+	if err == nil {
+		t.Fatal("expected invalid config")
+	}
+	for _, field := range []string{"rpc.port", "dag.k", "log.level", "network.bootstrappers"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("expected %s to be reported, got %s", field, err)
+		}
+	}
+}
+
+func TestEnvVars(t *testing.T) {
+	vars := strings.Join(EnvVars(), " ")
+	for _, name := range []string{"SPORE_RPC_PORT", "SPORE_NETWORK_CONNMGR_GRACE_PERIOD", "SPORE_DAG_K", "SPORE_LOG_LEVEL"} {
+		if !strings.Contains(vars, name) {
+			t.Errorf("expected %s, got %s", name, vars)
+		}
+	}
+	if strings.Contains(vars, "GENESIS") || strings.Contains(vars, "SPORE_CLUSTER_KEY") {
+		t.Errorf("expected no variable for genesis and legacy fields, got %s", vars)
+	}
+}
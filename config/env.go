@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EnvPrefix is the prefix of the environment variables of the configuration.
+// A setting is named by its section and field, so that rpc.port is
+// SPORE_RPC_PORT and network.connMgr.lowWater is
+// SPORE_NETWORK_CONNMGR_LOW_WATER. Lists are comma-separated.
+const EnvPrefix = "SPORE"
+
+var durationType = reflect.TypeOf(Duration{})
+
+// EnvVars returns the names of the environment variables of the configuration,
+// in the order of its fields
+func EnvVars() []string {
+	var names []string
+	walkEnv(reflect.ValueOf(Default()).Elem(), EnvPrefix, func(name string, _ reflect.Value) error {
+		names = append(names, name)
+		return nil
+	})
+	return names
+}
+
+// applyEnv overrides the configuration with the variables returned by lookup
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	return walkEnv(reflect.ValueOf(c).Elem(), EnvPrefix, func(name string, v reflect.Value) error {
+		s, ok := lookup(name)
+		if !ok {
+			return nil
+		}
+		if err := setValue(v, s); err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		return nil
+	})
+}
+
+// walkEnv calls fn with the environment variable name of every setting of
+// the struct v. Maps and the legacy fields have no variable.
+func walkEnv(v reflect.Value, prefix string, fn func(name string, v reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == "" || strings.HasPrefix(field.Name, "Legacy") || field.Type.Kind() == reflect.Map {
+			continue
+		}
+
+		name := prefix + "_" + envName(tag)
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			// sections are named by their tag as a single word
+			name = prefix + "_" + strings.ToUpper(tag)
+			if err := walkEnv(v.Field(i), name, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(name, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// envName converts a camel case field name to upper snake case
+func envName(tag string) string {
+	var b strings.Builder
+	for i, r := range tag {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// setValue parses s into the setting v
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		return v.Addr().Interface().(*Duration).Set(s)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var list []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported setting of type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multiaddr"
+	log "github.com/sirupsen/logrus"
+)
+
+// Validate checks every setting of the configuration and returns an error
+// listing all the invalid ones
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	n := c.Network
+	check(n.ListenHost != "", "network.listenHost is required")
+	check(n.Port >= 0 && n.Port <= 65535, "network.port %d is not a port", n.Port)
+	if secret, err := hex.DecodeString(n.ClusterKey); err != nil {
+		problems = append(problems, fmt.Sprintf("network.clusterKey is not hex: %s", err))
+	} else {
+		check(len(secret) == 0 || len(secret) == 32, "network.clusterKey is %d bytes, it should be 32", len(secret))
+	}
+	for _, s := range n.Bootstrappers {
+		addr, err := multiaddr.NewMultiaddr(s)
+		if err == nil {
+			_, err = peer.AddrInfoFromP2pAddr(addr)
+		}
+		check(err == nil, "network.bootstrappers: %q is not a peer address: %v", s, err)
+	}
+	check(n.ConnMgr.LowWater >= 0, "network.connMgr.lowWater must not be negative")
+	check(n.ConnMgr.HighWater >= n.ConnMgr.LowWater, "network.connMgr.highWater must be at least lowWater")
+	check(n.ConnMgr.GracePeriod.Duration >= 0, "network.connMgr.gracePeriod must not be negative")
+	check(n.MdnsInterval.Duration >= 0, "network.mdnsInterval must not be negative")
+
+	check(c.RPC.Port >= 0 && c.RPC.Port <= 65535, "rpc.port %d is not a port", c.RPC.Port)
+	check(c.RPC.MinGasPrice >= 0, "rpc.minGasPrice must not be negative")
+	check(c.RPC.MaxBatchSize > 0, "rpc.maxBatchSize must be positive")
+
+	check(c.DAG.K > 0, "dag.k must be positive")
+	check(c.DAG.ConfirmationDepth >= 0, "dag.confirmationDepth must not be negative")
+
+	check(c.Mempool.Size > 0, "mempool.size must be positive")
+	check(c.Mempool.BatchSize > 0, "mempool.batchSize must be positive")
+	check(c.Mempool.Interval.Duration > 0, "mempool.interval must be positive")
+
+	check(c.Storage.Path != "", "storage.path is required")
+
+	check(c.Contracts.QueryGasLimit > 0, "contracts.queryGasLimit must be positive")
+	check(c.Contracts.MaxWasmSize > 0, "contracts.maxWasmSize must be positive")
+	check(c.Contracts.MaxDataSize > 0, "contracts.maxDataSize must be positive")
+
+	_, err := log.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level %q is not one of trace, debug, info, warning, error, fatal or panic", c.Log.Level)
+	check(c.Log.Format == "text" || c.Log.Format == "json", "log.format %q is not text or json", c.Log.Format)
+	check(c.Log.Output != "", "log.output is required")
+
+	for address, balance := range c.Genesis {
+		b, err := hex.DecodeString(strings.TrimPrefix(address, "0x"))
+		check(err == nil && len(b) == 20, "genesis: %q is not a hex-encoded 20-byte address", address)
+		check(balance >= 0, "genesis: balance of %s must not be negative", address)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	wasmtime "github.com/bytecodealliance/wasmtime-go"
+)
+
+// The contract call ABI
+//
+// A call is encoded as the 4-byte selector of the exported function followed
+// by each argument as a uvarint length and the argument bytes. Every argument
+// is copied into the contract's exported "memory" at a pointer returned by its
+// exported "alloc(len i32) i32" function, and passed to the function as an
+// (i32 pointer, i32 length) pair.
+//
+// A function that returns an i64 returns a buffer, packed as the pointer in
+// the high 32 bits and the length in the low 32 bits, which is read back out
+// of memory. Any other single result is returned as its little-endian bytes,
+// and a function without results returns no data.
+
+const (
+	// SelectorLength is the length of a function selector
+	SelectorLength = 4
+
+	// allocExport is the export that allocates argument buffers
+	allocExport = "alloc"
+
+	// memoryExport is the memory that arguments and results are passed in
+	memoryExport = "memory"
+)
+
+var errInvalidCall = errors.New("invalid call encoding")
+
+// Selector returns the selector of an exported function: the first 4 bytes of
+// the sha256 hash of its name
+func Selector(funcName string) [SelectorLength]byte {
+	var selector [SelectorLength]byte
+	sum := sha256.Sum256([]byte(funcName))
+	copy(selector[:], sum[:SelectorLength])
+	return selector
+}
+
+// EncodeCall encodes a call of the named function with byte arguments
+func EncodeCall(funcName string, args ...[]byte) []byte {
+	selector := Selector(funcName)
+	data := append([]byte{}, selector[:]...)
+
+	lenBytes := make([]byte, binary.MaxVarintLen64)
+	for _, arg := range args {
+		n := binary.PutUvarint(lenBytes, uint64(len(arg)))
+		data = append(data, lenBytes[:n]...)
+		data = append(data, arg...)
+	}
+	return data
+}
+
+// DecodeCall decodes the selector and arguments of an encoded call
+func DecodeCall(data []byte) (selector [SelectorLength]byte, args [][]byte, err error) {
+	if len(data) < SelectorLength {
+		return selector, nil, errInvalidCall
+	}
+	copy(selector[:], data)
+	data = data[SelectorLength:]
+
+	for len(data) > 0 {
+		size, n := binary.Uvarint(data)
+		if n <= 0 || size > uint64(len(data)-n) {
+			return selector, nil, errInvalidCall
+		}
+		data = data[n:]
+		args = append(args, data[:size])
+		data = data[size:]
+	}
+	return selector, args, nil
+}
+
+// lookupSelector returns the exported function of a contract with the selector
+func lookupSelector(contract *wasmContract, selector [SelectorLength]byte) (*wasmtime.Func, error) {
+	for _, export := range contract.module.Exports() {
+		if export.Type().FuncType() == nil || Selector(export.Name()) != selector {
+			continue
+		}
+		return contract.instance.GetExport(export.Name()).Func(), nil
+	}
+	return nil, fmt.Errorf("contract has no exported function with selector %x", selector)
+}
+
+// contractMemory returns the exported memory of a contract
+func contractMemory(contract *wasmContract) (*wasmtime.Memory, error) {
+	export := contract.instance.GetExport(memoryExport)
+	if export == nil || export.Memory() == nil {
+		return nil, fmt.Errorf("contract has no exported %s", memoryExport)
+	}
+	return export.Memory(), nil
+}
+
+// writeArgs copies the arguments into the contract's memory and returns the
+// (pointer, length) parameters to call a function with
+func writeArgs(contract *wasmContract, args [][]byte) ([]interface{}, error) {
+	params := make([]interface{}, 0, 2*len(args))
+	if len(args) == 0 {
+		return params, nil
+	}
+
+	export := contract.instance.GetExport(allocExport)
+	if export == nil || export.Func() == nil {
+		return nil, fmt.Errorf("contract has no exported %s function", allocExport)
+	}
+	alloc := export.Func()
+
+	memory, err := contractMemory(contract)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arg := range args {
+		if len(arg) > math.MaxInt32 {
+			return nil, errors.New("argument too large")
+		}
+
+		result, err := alloc.Call(int32(len(arg)))
+		if err != nil {
+			return nil, err
+		}
+		ptr, ok := result.(int32)
+		if !ok {
+			return nil, fmt.Errorf("%s must return an i32 pointer", allocExport)
+		}
+
+		// the memory may have grown during alloc
+		data := memory.UnsafeData()
+		if ptr < 0 || uint64(ptr)+uint64(len(arg)) > uint64(len(data)) {
+			return nil, fmt.Errorf("%s returned out of bounds pointer %d", allocExport, ptr)
+		}
+		copy(data[ptr:], arg)
+
+		params = append(params, ptr, int32(len(arg)))
+	}
+	return params, nil
+}
+
+// readResult converts the result of a function call into return data
+func readResult(contract *wasmContract, result interface{}) ([]byte, error) {
+	switch value := result.(type) {
+	case nil:
+		return nil, nil
+	case int32:
+		data := make([]byte, 4)
+		binary.LittleEndian.PutUint32(data, uint32(value))
+		return data, nil
+	case float32:
+		data := make([]byte, 4)
+		binary.LittleEndian.PutUint32(data, math.Float32bits(value))
+		return data, nil
+	case float64:
+		data := make([]byte, 8)
+		binary.LittleEndian.PutUint64(data, math.Float64bits(value))
+		return data, nil
+	case int64:
+		memory, err := contractMemory(contract)
+		if err != nil {
+			return nil, err
+		}
+
+		ptr := uint64(value) >> 32
+		size := uint64(value) & math.MaxUint32
+		data := memory.UnsafeData()
+		if ptr+size > uint64(len(data)) {
+			return nil, fmt.Errorf("return buffer at %d of %d bytes is out of bounds", ptr, size)
+		}
+		return append([]byte{}, data[ptr:ptr+size]...), nil
+	default:
+		return nil, errors.New("functions must return at most one value")
+	}
+}
@@ -0,0 +1,101 @@
+package contract
+
+import (
+	"bytes"
+	"testing"
+
+	wasmtime "github.com/bytecodealliance/wasmtime-go"
+)
+
+// bufferWat is a contract with a bump allocator that echoes and measures its
+// byte arguments
+const bufferWat = `
+(module
+  (memory (export "memory") 1)
+  (global $next (mut i32) (i32.const 1024))
+  (func (export "alloc") (param $len i32) (result i32)
+    (local $ptr i32)
+    (local.set $ptr (global.get $next))
+    (global.set $next (i32.add (global.get $next) (local.get $len)))
+    (local.get $ptr))
+  (func (export "echo") (param $ptr i32) (param $len i32) (result i64)
+    (i64.or
+      (i64.shl (i64.extend_i32_u (local.get $ptr)) (i64.const 32))
+      (i64.extend_i32_u (local.get $len))))
+  (func (export "total") (param i32 i32 i32 i32) (result i32)
+    (i32.add (local.get 1) (local.get 3)))
+  (func (export "noop")))
+`
+
+func Test_EncodeDecodeCall(t *testing.T) {
+	data := EncodeCall("transfer", []byte("alice"), []byte{}, bytes.Repeat([]byte{7}, 300))
+
+	selector, args, err := DecodeCall(data)
+	if err != nil {
+		t.Fatalf("Error decoding call: %s", err)
+	}
+	if selector != Selector("transfer") {
+		t.Errorf("Incorrect selector %x", selector)
+	}
+	if len(args) != 3 || string(args[0]) != "alice" || len(args[1]) != 0 || len(args[2]) != 300 {
+		t.Errorf("Incorrect arguments decoded: %v", args)
+	}
+
+	// truncated arguments are rejected
+	_, _, err = DecodeCall(data[:len(data)-1])
+	if err == nil {
+		t.Error("Expected error decoding a truncated call")
+	}
+	_, _, err = DecodeCall(data[:2])
+	if err == nil {
+		t.Error("Expected error decoding a call without a selector")
+	}
+}
+
+func Test_CallArguments(t *testing.T) {
+	eng, err := NewContractEngine()
+	if err != nil {
+		t.Error("Error constructing Wasm Contract Engine")
+	}
+
+	wasm, err := wasmtime.Wat2Wasm(bufferWat)
+	if err != nil {
+		t.Fatalf("Error compiling wat: %s", err)
+	}
+
+	hash, _, err := eng.CreateWasmContract(wasm)
+	if err != nil {
+		t.Fatalf("Error creating Wasm contract: %s", err)
+	}
+
+	result, _, err := eng.Call(hash, nil, 100000, EncodeCall("echo", []byte("hello spore")))
+	if err != nil {
+		t.Fatalf("Error calling 'echo' function on Wasm contract: %s", err)
+	}
+	if string(result) != "hello spore" {
+		t.Errorf("Incorrect result from contract call returned, was %q", result)
+	}
+
+	result, _, err = eng.Call(hash, nil, 100000, EncodeCall("total", []byte("abc"), []byte("de")))
+	if err != nil {
+		t.Fatalf("Error calling 'total' function on Wasm contract: %s", err)
+	}
+	if resultInt32(t, result) != 5 {
+		t.Errorf("Incorrect result from contract call returned, was %d, expected %d", resultInt32(t, result), 5)
+	}
+
+	result, _, err = eng.Call(hash, nil, 100000, EncodeCall("noop"))
+	if err != nil || result != nil {
+		t.Errorf("Expected no return data, got %v with error %v", result, err)
+	}
+
+	_, _, err = eng.Call(hash, nil, 100000, EncodeCall("echo"))
+	if err == nil {
+		t.Error("Expected error calling a function with missing arguments")
+	}
+
+	_, _, err = eng.Call(hash, nil, 100000, EncodeCall("missing"))
+	if err == nil {
+		t.Error("Expected error calling a function that is not exported")
+	}
+}
@@ -0,0 +1,312 @@
+package contract
+
+import (
+	"errors"
+
+	wasmtime "github.com/bytecodealliance/wasmtime-go"
+)
+
+// HostModule is the import module of the host functions available to contracts
+const HostModule = "spore"
+
+// Gas charged by host functions, on top of the gas metered in the contract
+const (
+	// HostCallGas is charged for every host function call
+	HostCallGas = 10
+	// HostByteGas is charged per byte copied between a contract and the host
+	HostByteGas = 1
+	// StorageReadGas is charged for reading a storage key
+	StorageReadGas = 200
+	// StorageWriteGas is charged for setting or deleting a storage key
+	StorageWriteGas = 5000
+	// EventGas is charged for emitting an event
+	EventGas = 375
+)
+
+var storagePrefix = []byte("storage/")
+
+var errNoCall = errors.New("host function called outside of a contract call")
+
+// Environment is the transaction context a contract is called in
+type Environment struct {
+	// Caller is the address of the account that sent the transaction
+	Caller []byte
+	// TransactionID is the id of the transaction being executed
+	TransactionID []byte
+	// BlueScore and Height are the position of the transaction in the DAG
+	BlueScore int64
+	Height    int64
+
+	// Events is set by Call and Query to the events emitted by a successful call
+	Events []Event
+}
+
+// Event is a topic and data emitted by a contract during a call
+type Event struct {
+	Contract [32]byte
+	Topic    []byte
+	Data     []byte
+}
+
+// callState is the state of the running call that host functions act on.
+// Storage writes are buffered in it and only committed if the call succeeds.
+type callState struct {
+	contractID [32]byte
+	env        *Environment
+	// writes maps storage keys to their new value, or to nil if deleted
+	writes map[string][]byte
+	events []Event
+}
+
+// newLinker defines the host functions of the engine in a store
+func (engine *ContractEngine) newLinker(store *wasmtime.Store) (*wasmtime.Linker, error) {
+	linker := wasmtime.NewLinker(store)
+
+	funcs := map[string]interface{}{
+		"storage_get":    engine.storageGet,
+		"storage_set":    engine.storageSet,
+		"storage_delete": engine.storageDelete,
+		"caller":         engine.caller,
+		"self":           engine.self,
+		"transaction_id": engine.transactionID,
+		"blue_score":     engine.blueScore,
+		"height":         engine.height,
+		"emit_event":     engine.emitEvent,
+	}
+	for name, f := range funcs {
+		err := linker.DefineFunc(HostModule, name, f)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := linker.DefineFunc("metering", "usegas", engine.gasConsumed)
+	if err != nil {
+		return nil, err
+	}
+	return linker, nil
+}
+
+// hostCall charges the gas of a host function call and returns the state of
+// the running call
+func (engine *ContractEngine) hostCall(gas int64) (*callState, *wasmtime.Trap) {
+	if engine.current == nil {
+		return nil, engine.trap(errNoCall.Error())
+	}
+	return engine.current, engine.gasConsumed(HostCallGas + gas)
+}
+
+// readMemory copies a buffer out of the calling contract's memory, charging
+// for each byte
+func (engine *ContractEngine) readMemory(c *wasmtime.Caller, ptr, size int32) ([]byte, *wasmtime.Trap) {
+	data, trap := engine.callerMemory(c, ptr, size)
+	if trap != nil {
+		return nil, trap
+	}
+	if trap = engine.gasConsumed(int64(size) * HostByteGas); trap != nil {
+		return nil, trap
+	}
+	return append([]byte{}, data...), nil
+}
+
+// writeMemory copies as much of value as fits into a buffer of the calling
+// contract's memory, charging for each byte, and returns the full length of
+// value
+func (engine *ContractEngine) writeMemory(c *wasmtime.Caller, ptr, capacity int32, value []byte) (int32, *wasmtime.Trap) {
+	data, trap := engine.callerMemory(c, ptr, capacity)
+	if trap != nil {
+		return 0, trap
+	}
+	n := copy(data, value)
+	if trap = engine.gasConsumed(int64(n) * HostByteGas); trap != nil {
+		return 0, trap
+	}
+	return int32(len(value)), nil
+}
+
+// callerMemory returns a buffer of the calling contract's exported memory
+func (engine *ContractEngine) callerMemory(c *wasmtime.Caller, ptr, size int32) ([]byte, *wasmtime.Trap) {
+	export := c.GetExport(memoryExport)
+	if export == nil || export.Memory() == nil {
+		return nil, engine.trap("contract has no exported " + memoryExport)
+	}
+
+	data := export.Memory().UnsafeData()
+	if ptr < 0 || size < 0 || int64(ptr)+int64(size) > int64(len(data)) {
+		return nil, engine.trap("memory access out of bounds")
+	}
+	return data[ptr : ptr+size], nil
+}
+
+// storageGet copies the value of a storage key into the buffer and returns its
+// length, or -1 if the key is not set
+func (engine *ContractEngine) storageGet(c *wasmtime.Caller, keyPtr, keyLen, valuePtr, valueCap int32) (int32, *wasmtime.Trap) {
+	call, trap := engine.hostCall(StorageReadGas)
+	if trap != nil {
+		return 0, trap
+	}
+	key, trap := engine.readMemory(c, keyPtr, keyLen)
+	if trap != nil {
+		return 0, trap
+	}
+
+	value, ok, err := engine.getStorage(call, key)
+	if err != nil {
+		return 0, engine.trap(err.Error())
+	}
+	if !ok {
+		return -1, nil
+	}
+	return engine.writeMemory(c, valuePtr, valueCap, value)
+}
+
+// storageSet sets a storage key of the contract
+func (engine *ContractEngine) storageSet(c *wasmtime.Caller, keyPtr, keyLen, valuePtr, valueLen int32) *wasmtime.Trap {
+	call, trap := engine.hostCall(StorageWriteGas)
+	if trap != nil {
+		return trap
+	}
+	key, trap := engine.readMemory(c, keyPtr, keyLen)
+	if trap != nil {
+		return trap
+	}
+	value, trap := engine.readMemory(c, valuePtr, valueLen)
+	if trap != nil {
+		return trap
+	}
+
+	call.writes[string(key)] = value
+	return nil
+}
+
+// storageDelete deletes a storage key of the contract
+func (engine *ContractEngine) storageDelete(c *wasmtime.Caller, keyPtr, keyLen int32) *wasmtime.Trap {
+	call, trap := engine.hostCall(StorageWriteGas)
+	if trap != nil {
+		return trap
+	}
+	key, trap := engine.readMemory(c, keyPtr, keyLen)
+	if trap != nil {
+		return trap
+	}
+
+	call.writes[string(key)] = nil
+	return nil
+}
+
+// caller copies the address of the transaction sender into the buffer
+func (engine *ContractEngine) caller(c *wasmtime.Caller, ptr, capacity int32) (int32, *wasmtime.Trap) {
+	call, trap := engine.hostCall(0)
+	if trap != nil {
+		return 0, trap
+	}
+	return engine.writeMemory(c, ptr, capacity, call.env.Caller)
+}
+
+// self copies the id of the called contract into the buffer
+func (engine *ContractEngine) self(c *wasmtime.Caller, ptr, capacity int32) (int32, *wasmtime.Trap) {
+	call, trap := engine.hostCall(0)
+	if trap != nil {
+		return 0, trap
+	}
+	return engine.writeMemory(c, ptr, capacity, call.contractID[:])
+}
+
+// transactionID copies the id of the executing transaction into the buffer
+func (engine *ContractEngine) transactionID(c *wasmtime.Caller, ptr, capacity int32) (int32, *wasmtime.Trap) {
+	call, trap := engine.hostCall(0)
+	if trap != nil {
+		return 0, trap
+	}
+	return engine.writeMemory(c, ptr, capacity, call.env.TransactionID)
+}
+
+// blueScore returns the blue score of the executing transaction
+func (engine *ContractEngine) blueScore() (int64, *wasmtime.Trap) {
+	call, trap := engine.hostCall(0)
+	if trap != nil {
+		return 0, trap
+	}
+	return call.env.BlueScore, nil
+}
+
+// height returns the DAG height of the executing transaction
+func (engine *ContractEngine) height() (int64, *wasmtime.Trap) {
+	call, trap := engine.hostCall(0)
+	if trap != nil {
+		return 0, trap
+	}
+	return call.env.Height, nil
+}
+
+// emitEvent records an event, which is published if the call succeeds
+func (engine *ContractEngine) emitEvent(c *wasmtime.Caller, topicPtr, topicLen, dataPtr, dataLen int32) *wasmtime.Trap {
+	call, trap := engine.hostCall(EventGas)
+	if trap != nil {
+		return trap
+	}
+	topic, trap := engine.readMemory(c, topicPtr, topicLen)
+	if trap != nil {
+		return trap
+	}
+	data, trap := engine.readMemory(c, dataPtr, dataLen)
+	if trap != nil {
+		return trap
+	}
+
+	call.events = append(call.events, Event{Contract: call.contractID, Topic: topic, Data: data})
+	return nil
+}
+
+// storageKey returns the key a contract's storage key is committed under
+func storageKey(contractID [32]byte, key []byte) []byte {
+	return append(contractKey(storagePrefix, contractID), key...)
+}
+
+// getStorage returns the value of a storage key, as written by the running
+// call or last committed
+func (engine *ContractEngine) getStorage(call *callState, key []byte) ([]byte, bool, error) {
+	if value, ok := call.writes[string(key)]; ok {
+		return value, value != nil, nil
+	}
+
+	committedKey := storageKey(call.contractID, key)
+	if engine.db == nil {
+		value, ok := engine.storage[string(committedKey)]
+		return value, ok, nil
+	}
+
+	ok, err := engine.db.Has(engine.namespace, committedKey)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	value, err := engine.db.Get(engine.namespace, committedKey)
+	return value, err == nil, err
+}
+
+// commitStorage commits the storage writes of a successful call
+func (engine *ContractEngine) commitStorage(call *callState) error {
+	for key, value := range call.writes {
+		committedKey := storageKey(call.contractID, []byte(key))
+
+		if engine.db == nil {
+			if value == nil {
+				delete(engine.storage, string(committedKey))
+			} else {
+				engine.storage[string(committedKey)] = value
+			}
+			continue
+		}
+
+		var err error
+		if value == nil {
+			err = engine.db.Delete(engine.namespace, committedKey)
+		} else {
+			err = engine.db.Set(engine.namespace, committedKey, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
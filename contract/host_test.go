@@ -0,0 +1,218 @@
+package contract
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	wasmtime "github.com/bytecodealliance/wasmtime-go"
+	"github.com/sporeframework/spore/db"
+)
+
+// hostWat is a contract that keeps a counter in storage and reads its
+// environment through the host API
+const hostWat = `
+(module
+  (import "spore" "storage_get" (func $get (param i32 i32 i32 i32) (result i32)))
+  (import "spore" "storage_set" (func $set (param i32 i32 i32 i32)))
+  (import "spore" "storage_delete" (func $delete (param i32 i32)))
+  (import "spore" "caller" (func $caller (param i32 i32) (result i32)))
+  (import "spore" "blue_score" (func $blue_score (result i64)))
+  (import "spore" "emit_event" (func $emit (param i32 i32 i32 i32)))
+  (memory (export "memory") 1)
+  (data (i32.const 0) "count")
+  (func (export "count") (result i32)
+    (i32.store (i32.const 16) (i32.const 0))
+    (drop (call $get (i32.const 0) (i32.const 5) (i32.const 16) (i32.const 4)))
+    (i32.store (i32.const 16) (i32.add (i32.load (i32.const 16)) (i32.const 1)))
+    (call $set (i32.const 0) (i32.const 5) (i32.const 16) (i32.const 4))
+    (call $emit (i32.const 0) (i32.const 5) (i32.const 16) (i32.const 4))
+    (i32.load (i32.const 16)))
+  (func (export "fail")
+    (call $set (i32.const 0) (i32.const 5) (i32.const 0) (i32.const 4))
+    unreachable)
+  (func (export "reset")
+    (call $delete (i32.const 0) (i32.const 5)))
+  (func (export "caller") (result i64)
+    (i64.or
+      (i64.shl (i64.const 64) (i64.const 32))
+      (i64.extend_i32_u (call $caller (i32.const 64) (i32.const 32)))))
+  (func (export "score") (result i32)
+    (i32.wrap_i64 (call $blue_score))))
+`
+
+func createHostContract(t *testing.T, eng *ContractEngine) [32]byte {
+	wasm, err := wasmtime.Wat2Wasm(hostWat)
+	if err != nil {
+		t.Fatalf("Error compiling wat: %s", err)
+	}
+
+	hash, _, err := eng.CreateWasmContract(wasm)
+	if err != nil {
+		t.Fatalf("Error creating Wasm contract: %s", err)
+	}
+	return hash
+}
+
+func Test_HostStorage(t *testing.T) {
+	eng, err := NewContractEngine()
+	if err != nil {
+		t.Error("Error constructing Wasm Contract Engine")
+	}
+	hash := createHostContract(t, eng)
+
+	for i := int32(1); i <= 3; i++ {
+		env := &Environment{}
+		result, _, err := eng.Call(hash, env, 100000, EncodeCall("count"))
+		if err != nil {
+			t.Fatalf("Error calling 'count' function on Wasm contract: %s", err)
+		}
+		if resultInt32(t, result) != i {
+			t.Errorf("Incorrect result from contract call returned, was %d, expected %d", resultInt32(t, result), i)
+		}
+		if len(env.Events) != 1 || string(env.Events[0].Topic) != "count" || env.Events[0].Contract != hash {
+			t.Errorf("Incorrect events emitted: %v", env.Events)
+		}
+	}
+
+	// the writes and events of a failed call are discarded
+	env := &Environment{}
+	_, _, err = eng.Call(hash, env, 100000, EncodeCall("fail"))
+	if err == nil {
+		t.Error("Expected error calling 'fail' function on Wasm contract")
+	}
+	if len(env.Events) != 0 {
+		t.Errorf("Expected no events from a failed call, got %v", env.Events)
+	}
+
+	result, _, err := eng.Call(hash, nil, 100000, EncodeCall("count"))
+	if err != nil || resultInt32(t, result) != 4 {
+		t.Errorf("Expected count of 4 after a failed call, got %v with error %v", result, err)
+	}
+
+	_, _, err = eng.Call(hash, nil, 100000, EncodeCall("reset"))
+	if err != nil {
+		t.Fatalf("Error calling 'reset' function on Wasm contract: %s", err)
+	}
+
+	result, _, err = eng.Call(hash, nil, 100000, EncodeCall("count"))
+	if err != nil || resultInt32(t, result) != 1 {
+		t.Errorf("Expected count of 1 after reset, got %v with error %v", result, err)
+	}
+}
+
+func Test_HostEnvironment(t *testing.T) {
+	eng, err := NewContractEngine()
+	if err != nil {
+		t.Error("Error constructing Wasm Contract Engine")
+	}
+	hash := createHostContract(t, eng)
+
+	caller := bytes.Repeat([]byte{0xab}, 20)
+	env := &Environment{Caller: caller, BlueScore: 42}
+
+	result, _, err := eng.Call(hash, env, 100000, EncodeCall("caller"))
+	if err != nil {
+		t.Fatalf("Error calling 'caller' function on Wasm contract: %s", err)
+	}
+	if !bytes.Equal(result, caller) {
+		t.Errorf("Incorrect caller returned, was %x, expected %x", result, caller)
+	}
+
+	result, _, err = eng.Call(hash, env, 100000, EncodeCall("score"))
+	if err != nil {
+		t.Fatalf("Error calling 'score' function on Wasm contract: %s", err)
+	}
+	if resultInt32(t, result) != 42 {
+		t.Errorf("Incorrect blue score returned, was %d, expected %d", resultInt32(t, result), 42)
+	}
+}
+
+func Test_HostGas(t *testing.T) {
+	eng, err := NewContractEngine()
+	if err != nil {
+		t.Error("Error constructing Wasm Contract Engine")
+	}
+	hash := createHostContract(t, eng)
+
+	_, gasUsed, err := eng.Call(hash, nil, 100000, EncodeCall("count"))
+	if err != nil {
+		t.Fatalf("Error calling 'count' function on Wasm contract: %s", err)
+	}
+	if gasUsed < StorageReadGas+StorageWriteGas+EventGas {
+		t.Errorf("Host calls were not charged, gas used %d", gasUsed)
+	}
+
+	// a storage write does not fit in the limit
+	_, gasUsed, err = eng.Call(hash, nil, StorageWriteGas, EncodeCall("count"))
+	if err != ErrOutOfGas || gasUsed != StorageWriteGas {
+		t.Errorf("Expected out of gas at limit %d, got %v with gas %d", StorageWriteGas, err, gasUsed)
+	}
+
+	result, _, err := eng.Call(hash, nil, 100000, EncodeCall("count"))
+	if err != nil || resultInt32(t, result) != 2 {
+		t.Errorf("Expected count of 2 after an out of gas call, got %v with error %v", result, err)
+	}
+}
+
+func Test_HostStoragePersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spore-contract")
+	if err != nil {
+		t.Fatalf("Error creating database directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	database, err := db.NewBadgerDB(dir)
+	if err != nil {
+		t.Fatalf("Error opening database: %s", err)
+	}
+	defer database.Close()
+
+	eng, err := NewPersistentContractEngine(database, []byte("contracts"))
+	if err != nil {
+		t.Error("Error constructing Wasm Contract Engine")
+	}
+	hash := createHostContract(t, eng)
+
+	for i := 0; i < 2; i++ {
+		_, _, err = eng.Call(hash, nil, 100000, EncodeCall("count"))
+		if err != nil {
+			t.Fatalf("Error calling 'count' function on Wasm contract: %s", err)
+		}
+	}
+
+	// a new engine on the same database acts as a restarted node
+	restarted, err := NewPersistentContractEngine(database, []byte("contracts"))
+	if err != nil {
+		t.Error("Error constructing Wasm Contract Engine")
+	}
+
+	result, _, err := restarted.Call(hash, nil, 100000, EncodeCall("count"))
+	if err != nil || resultInt32(t, result) != 3 {
+		t.Errorf("Expected count of 3 after restart, got %v with error %v", result, err)
+	}
+}
+
+func Test_HostQuery(t *testing.T) {
+	eng, err := NewContractEngine()
+	if err != nil {
+		t.Error("Error constructing Wasm Contract Engine")
+	}
+	hash := createHostContract(t, eng)
+
+	env := &Environment{}
+	result, _, err := eng.Query(hash, env, 100000, EncodeCall("count"))
+	if err != nil || resultInt32(t, result) != 1 {
+		t.Errorf("Expected query count of 1, got %v with error %v", result, err)
+	}
+	if len(env.Events) != 1 {
+		t.Errorf("Incorrect events emitted by query: %v", env.Events)
+	}
+
+	// the storage write of the query was discarded
+	result, _, err = eng.Call(hash, nil, 100000, EncodeCall("count"))
+	if err != nil || resultInt32(t, result) != 1 {
+		t.Errorf("Expected count of 1 after a query, got %v with error %v", result, err)
+	}
+}
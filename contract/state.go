@@ -0,0 +1,222 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	wasmtime "github.com/bytecodealliance/wasmtime-go"
+)
+
+var (
+	codePrefix  = []byte("code/")
+	statePrefix = []byte("state/")
+)
+
+// wasmPageSize is the size of a page of wasm linear memory
+const wasmPageSize = 65536
+
+// instanceState is a snapshot of the exported, mutable state of a contract
+// instance: its linear memories and its mutable globals. State that a
+// contract does not export cannot be captured.
+type instanceState struct {
+	Memories map[string][]byte      `json:"memories"`
+	Globals  map[string]globalState `json:"globals"`
+}
+
+// globalState is the value of a global, stored as its raw bits
+type globalState struct {
+	Kind wasmtime.ValKind `json:"kind"`
+	Bits uint64           `json:"bits"`
+}
+
+// snapshot captures the exported memories and mutable globals of an instance
+func snapshot(module *wasmtime.Module, instance *wasmtime.Instance) (*instanceState, error) {
+	state := &instanceState{
+		Memories: make(map[string][]byte),
+		Globals:  make(map[string]globalState),
+	}
+
+	for _, export := range module.Exports() {
+		extern := instance.GetExport(export.Name())
+		if extern == nil {
+			continue
+		}
+
+		if memory := extern.Memory(); memory != nil {
+			data := make([]byte, memory.DataSize())
+			copy(data, memory.UnsafeData())
+			state.Memories[export.Name()] = data
+			continue
+		}
+
+		if global := extern.Global(); global != nil && global.Type().Mutable() {
+			value := global.Get()
+			var bits uint64
+			switch value.Kind() {
+			case wasmtime.KindI32:
+				bits = uint64(uint32(value.I32()))
+			case wasmtime.KindI64:
+				bits = uint64(value.I64())
+			case wasmtime.KindF32:
+				bits = uint64(math.Float32bits(value.F32()))
+			case wasmtime.KindF64:
+				bits = math.Float64bits(value.F64())
+			default:
+				return nil, fmt.Errorf("unsupported type of global %s", export.Name())
+			}
+			state.Globals[export.Name()] = globalState{Kind: value.Kind(), Bits: bits}
+		}
+	}
+
+	return state, nil
+}
+
+// restore writes a snapshot back into the exported memories and globals of a
+// freshly created instance, growing memories as needed.
+func restore(instance *wasmtime.Instance, state *instanceState) error {
+	for name, data := range state.Memories {
+		extern := instance.GetExport(name)
+		if extern == nil || extern.Memory() == nil {
+			return fmt.Errorf("contract has no exported memory %s", name)
+		}
+
+		memory := extern.Memory()
+		if uintptr(len(data)) > memory.DataSize() {
+			delta := (uintptr(len(data)) - memory.DataSize()) / wasmPageSize
+			if !memory.Grow(uint(delta)) {
+				return fmt.Errorf("failed to grow memory %s by %d pages", name, delta)
+			}
+		}
+		copy(memory.UnsafeData(), data)
+	}
+
+	for name, global := range state.Globals {
+		extern := instance.GetExport(name)
+		if extern == nil || extern.Global() == nil {
+			return fmt.Errorf("contract has no exported global %s", name)
+		}
+
+		var value wasmtime.Val
+		switch global.Kind {
+		case wasmtime.KindI32:
+			value = wasmtime.ValI32(int32(uint32(global.Bits)))
+		case wasmtime.KindI64:
+			value = wasmtime.ValI64(int64(global.Bits))
+		case wasmtime.KindF32:
+			value = wasmtime.ValF32(math.Float32frombits(uint32(global.Bits)))
+		case wasmtime.KindF64:
+			value = wasmtime.ValF64(math.Float64frombits(global.Bits))
+		default:
+			return fmt.Errorf("unsupported type of global %s", name)
+		}
+
+		err := extern.Global().Set(value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// contractKey returns the database key of a contract under the given prefix
+func contractKey(prefix []byte, contractID [32]byte) []byte {
+	key := make([]byte, 0, len(prefix)+len(contractID))
+	key = append(key, prefix...)
+	return append(key, contractID[:]...)
+}
+
+// storeCode commits the code of a contract to the database, or to memory if
+// there is none
+func (engine *ContractEngine) storeCode(contractID [32]byte, wasm []byte) error {
+	codeKey := contractKey(codePrefix, contractID)
+	if engine.db == nil {
+		engine.storage[string(codeKey)] = wasm
+		return nil
+	}
+	return engine.db.Set(engine.namespace, codeKey, wasm)
+}
+
+// storeState commits the current state of a contract to the database, or to
+// memory if there is none. The write is skipped when the state has not
+// changed since it was last stored.
+func (engine *ContractEngine) storeState(contractID [32]byte) error {
+	contract := engine.contracts[contractID]
+	state, err := snapshot(contract.module, contract.instance)
+	if err != nil {
+		return err
+	}
+
+	stateBytes, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	stateHash := sha256.Sum256(stateBytes)
+	if stateHash == contract.stateHash {
+		return nil
+	}
+
+	stateKey := contractKey(statePrefix, contractID)
+	if engine.db == nil {
+		engine.storage[string(stateKey)] = stateBytes
+	} else {
+		err = engine.db.Set(engine.namespace, stateKey, stateBytes)
+		if err != nil {
+			return err
+		}
+	}
+	contract.stateHash = stateHash
+	return nil
+}
+
+// getCommitted returns the value committed under a key, in the database or in
+// memory if there is none, or nil if there is no such key
+func (engine *ContractEngine) getCommitted(key []byte) ([]byte, error) {
+	if engine.db == nil {
+		return engine.storage[string(key)], nil
+	}
+
+	ok, err := engine.db.Has(engine.namespace, key)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return engine.db.Get(engine.namespace, key)
+}
+
+// load rehydrates a contract from its code and last committed state. It
+// returns nil if the contract does not exist.
+func (engine *ContractEngine) load(contractID [32]byte) (*wasmContract, error) {
+	wasm, err := engine.getCommitted(contractKey(codePrefix, contractID))
+	if err != nil || wasm == nil {
+		return nil, err
+	}
+
+	contract, _, err := engine.instantiate(wasm)
+	if err != nil {
+		return nil, err
+	}
+
+	stateBytes, err := engine.getCommitted(contractKey(statePrefix, contractID))
+	if err != nil {
+		return nil, err
+	}
+	if stateBytes != nil {
+		state := &instanceState{}
+		err = json.Unmarshal(stateBytes, state)
+		if err != nil {
+			return nil, err
+		}
+
+		err = restore(contract.instance, state)
+		if err != nil {
+			return nil, err
+		}
+		contract.stateHash = sha256.Sum256(stateBytes)
+	}
+
+	engine.contracts[contractID] = contract
+	return contract, nil
+}
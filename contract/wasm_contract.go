@@ -6,9 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"reflect"
+	"sync"
 
 	wasmtime "github.com/bytecodealliance/wasmtime-go"
+	"github.com/sporeframework/spore/db"
 	metering "github.com/sporeframework/spore/metering"
 
 	"github.com/mathetake/gasm/hostfunc"
@@ -16,69 +19,311 @@ import (
 	"github.com/mathetake/gasm/wasm"
 )
 
+// ErrOutOfGas is returned by Call when a contract exceeds its gas limit
+var ErrOutOfGas = errors.New("out of gas")
+
 type ContractEngine struct {
-	contracts  map[[32]byte]*wasmtime.Instance
-	store      *wasmtime.Store
+	// mu serializes calls, queries and deployments, which share the gas
+	// counter
+	mu sync.Mutex
+
+	contracts map[[32]byte]*wasmContract
+	// wasm compiles the modules of contracts, each instantiated in a store of
+	// its own, so that dropping an instance frees everything it allocated
+	wasm *wasmtime.Engine
+	// callStore is the store of the instance running, which host functions
+	// create their traps in
+	callStore  *wasmtime.Store
 	gasCounter int64
+	// gasLimit is the gas available to the running call
+	gasLimit int64
+	// outOfGas is set when the running call was trapped for exceeding gasLimit
+	outOfGas bool
+
+	// current is the state of the running call
+	current *callState
+
+	// db holds the code and committed state of contracts, if set
+	db        db.DB
+	namespace []byte
+	// storage holds the code, committed state and storage of contracts by
+	// database key if there is no db
+	storage map[string][]byte
+}
+
+// wasmContract is a live instance of a contract, in its own store
+type wasmContract struct {
+	store    *wasmtime.Store
+	module   *wasmtime.Module
+	instance *wasmtime.Instance
+	// stateHash is the hash of the last state committed to the database
+	stateHash [32]byte
 }
 
 func NewContractEngine() (*ContractEngine, error) {
 
 	eng := &ContractEngine{
-		contracts:  make(map[[32]byte]*wasmtime.Instance),
-		store:      wasmtime.NewStore(wasmtime.NewEngine()),
+		contracts:  make(map[[32]byte]*wasmContract),
+		wasm:       wasmtime.NewEngine(),
 		gasCounter: 0,
+		storage:    make(map[string][]byte),
 	}
 	return eng, nil
 }
 
-func (engine *ContractEngine) gasConsumed(gas int64) {
+// NewPersistentContractEngine returns a ContractEngine that commits contract
+// code and post-call state to the given namespace of the database. Contracts
+// are rehydrated from the database on their first call after startup.
+func NewPersistentContractEngine(database db.DB, namespace []byte) (*ContractEngine, error) {
+	eng, err := NewContractEngine()
+	if err != nil {
+		return nil, err
+	}
+	eng.db = database
+	eng.namespace = namespace
+	return eng, nil
+}
+
+// gasConsumed is the metering.usegas host function. It traps execution once
+// the gas used by the running call exceeds its limit.
+func (engine *ContractEngine) gasConsumed(gas int64) *wasmtime.Trap {
 	engine.gasCounter += gas
+	if engine.gasCounter > engine.gasLimit {
+		engine.outOfGas = true
+		return engine.trap(ErrOutOfGas.Error())
+	}
+	return nil
+}
+
+// trap returns a trap of the running instance with the message
+func (engine *ContractEngine) trap(message string) *wasmtime.Trap {
+	return wasmtime.NewTrap(engine.callStore, message)
 }
 
 // CreateWasmContract creates a new contract
 func (engine *ContractEngine) CreateWasmContract(wasm []byte) (sum [32]byte, gas uint64, err error) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
 
 	sum = sha256.Sum256(wasm)
-	if engine.contracts[sum] != nil {
+	existing, err := engine.getContract(sum)
+	if err != nil {
+		return sum, 0, err
+	}
+	if existing != nil {
 		return sum, 0, errors.New("Contract already exists")
 	}
 
+	contract, gas, err := engine.instantiate(wasm)
+	if err != nil {
+		return sum, 0, err
+	}
+
+	err = engine.storeCode(sum, wasm)
+	if err != nil {
+		return sum, 0, err
+	}
+
+	engine.contracts[sum] = contract
+	return sum, gas, nil
+}
+
+// DeploymentGas returns the gas CreateWasmContract charges for deploying the
+// wasm code, without deploying it
+func DeploymentGas(wasm []byte) (uint64, error) {
+	opts := &metering.Options{}
+	_, gas, err := metering.MeterWASM(wasm, opts)
+	return gas, err
+}
+
+// instantiate meters and compiles the wasm code, and creates a new instance of it
+func (engine *ContractEngine) instantiate(wasm []byte) (*wasmContract, uint64, error) {
 	opts := &metering.Options{}
-	meterWasm, gas, _ := metering.MeterWASM(wasm, opts)
+	meterWasm, gas, err := metering.MeterWASM(wasm, opts)
+	if err != nil {
+		return nil, 0, err
+	}
 	// Once we have our binary `wasm` we can compile that into a `*Module`
 	// which represents compiled JIT code.
-	module, err := wasmtime.NewModule(engine.store.Engine, meterWasm)
-	check(err)
+	module, err := wasmtime.NewModule(engine.wasm, meterWasm)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	item := wasmtime.WrapFunc(engine.store, engine.gasConsumed)
-	// Instantiate a module which is where we link in all our
-	// imports. We've got one import so we pass that in here.
-	instance, err := wasmtime.NewInstance(engine.store, module, []*wasmtime.Extern{item.AsExtern()})
-	check(err)
+	contract, err := engine.newInstance(module)
+	return contract, gas, err
+}
 
-	engine.contracts[sum] = instance
-	return sum, gas, nil
+// newInstance instantiates a compiled module in a new store, linking in all
+// our imports: the metering function and the host API
+func (engine *ContractEngine) newInstance(module *wasmtime.Module) (*wasmContract, error) {
+	store := wasmtime.NewStore(engine.wasm)
+	linker, err := engine.newLinker(store)
+	if err != nil {
+		return nil, err
+	}
+
+	// start functions run on a gas counter and without a call of their own,
+	// so that rehydrating a contract in the middle of a call charges that call
+	// nothing, the same on nodes where the contract is loaded already
+	defer func(callStore *wasmtime.Store, current *callState, gasCounter, gasLimit int64, outOfGas bool) {
+		engine.callStore, engine.current = callStore, current
+		engine.gasCounter, engine.gasLimit, engine.outOfGas = gasCounter, gasLimit, outOfGas
+	}(engine.callStore, engine.current, engine.gasCounter, engine.gasLimit, engine.outOfGas)
+	engine.callStore, engine.current = store, nil
+	engine.gasCounter, engine.gasLimit, engine.outOfGas = 0, math.MaxInt64, false
+
+	instance, err := linker.Instantiate(module)
+	if err != nil {
+		return nil, err
+	}
+	return &wasmContract{store: store, module: module, instance: instance}, nil
 }
 
-// Call calls a wasm contract function
-func (engine *ContractEngine) Call(contractID [32]byte, funcName string, args ...interface{}) (interface{}, int64, error) {
+// getContract returns the live instance of a contract, rehydrating it from
+// the database if needed. It returns nil if the contract does not exist.
+func (engine *ContractEngine) getContract(contractID [32]byte) (*wasmContract, error) {
+	contract := engine.contracts[contractID]
+	if contract != nil {
+		return contract, nil
+	}
+	return engine.load(contractID)
+}
+
+// Call calls a wasm contract function in the environment with the given gas
+// limit. The input is a call encoded with EncodeCall, and the return data is
+// the function's result as described by the call ABI. If the limit is
+// exceeded, execution is trapped and ErrOutOfGas is returned with the gas used
+// equal to the limit. Storage writes and events only take effect if the call
+// succeeds.
+func (engine *ContractEngine) Call(contractID [32]byte, env *Environment, gasLimit int64, input []byte) ([]byte, int64, error) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	return engine.run(contractID, env, gasLimit, input, false)
+}
 
-	// reset the gas counter
+// Query calls a wasm contract function like Call, but on a throwaway copy of
+// the contract: its memory, storage writes and events are discarded, and
+// nothing is committed to the database. It is safe to use concurrently with
+// Call.
+func (engine *ContractEngine) Query(contractID [32]byte, env *Environment, gasLimit int64, input []byte) ([]byte, int64, error) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	return engine.run(contractID, env, gasLimit, input, true)
+}
+
+// run calls a contract function, and commits the changes it made unless the
+// call is read only. The caller must hold mu.
+func (engine *ContractEngine) run(contractID [32]byte, env *Environment, gasLimit int64, input []byte, readOnly bool) ([]byte, int64, error) {
+	if env == nil {
+		env = &Environment{}
+	}
+
+	// reset the gas counter and the call state
 	defer func() {
 		engine.gasCounter = 0
+		engine.gasLimit = 0
+		engine.outOfGas = false
+		engine.current = nil
+		engine.callStore = nil
 	}()
+	engine.gasLimit = gasLimit
+	engine.current = &callState{
+		contractID: contractID,
+		env:        env,
+		writes:     make(map[string][]byte),
+	}
 
-	instance := engine.contracts[contractID]
+	selector, args, err := DecodeCall(input)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	if instance == nil {
+	contract, err := engine.getContract(contractID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if contract == nil {
 		return nil, 0, errors.New("contract could not be found")
 	}
-	run := instance.GetExport(funcName).Func()
-	result, err := run.Call(args...)
+	if readOnly {
+		contract, err = engine.fork(contract)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	engine.callStore = contract.store
 
-	// reset the gas counter
-	return result, engine.gasCounter, err
+	function, err := lookupSelector(contract, selector)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	output, err := engine.call(contract, function, args)
+	if err != nil {
+		// discard the partially modified instance, freed with its store; the
+		// next call rehydrates it from the last committed state
+		if !readOnly {
+			delete(engine.contracts, contractID)
+		}
+		if engine.outOfGas {
+			return nil, gasLimit, ErrOutOfGas
+		}
+		return nil, engine.gasCounter, err
+	}
+
+	env.Events = engine.current.events
+	if readOnly {
+		return output, engine.gasCounter, nil
+	}
+
+	err = engine.commitStorage(engine.current)
+	if err != nil {
+		return nil, engine.gasCounter, err
+	}
+
+	err = engine.storeState(contractID)
+	return output, engine.gasCounter, err
+}
+
+// fork returns a new instance of the contract with a copy of its state, in a
+// throwaway store that is freed once the instance is dropped
+func (engine *ContractEngine) fork(contract *wasmContract) (*wasmContract, error) {
+	state, err := snapshot(contract.module, contract.instance)
+	if err != nil {
+		return nil, err
+	}
+
+	forked, err := engine.newInstance(contract.module)
+	if err != nil {
+		return nil, err
+	}
+
+	err = restore(forked.instance, state)
+	if err != nil {
+		return nil, err
+	}
+	return forked, nil
+}
+
+// call writes the arguments into the contract, calls the function and reads
+// back its result. Gas used by the contract's allocator counts towards the call.
+func (engine *ContractEngine) call(contract *wasmContract, function *wasmtime.Func, args [][]byte) ([]byte, error) {
+	params, err := writeArgs(contract, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if function.ParamArity() != len(params) {
+		return nil, fmt.Errorf("function takes %d parameters, call has %d arguments", function.ParamArity(), len(args))
+	}
+
+	result, err := function.Call(params...)
+	if err != nil {
+		return nil, err
+	}
+	return readResult(contract, result)
 }
 
 // WasmTime test with wasmtime library
@@ -104,7 +349,7 @@ func WasmTime() {
 	for i := 1; i <= 10; i++ {
 
 		//result, err := Call(id, "addTwoNumbers", 42, 32)
-		result, gas, err := engine.Call(id, "increment")
+		result, gas, err := engine.Call(id, nil, 1000, EncodeCall("increment"))
 
 		//run := instance.GetExport("increment").Func()
 		//result, err := run.Call()
@@ -1,9 +1,15 @@
 package contract
 
 import (
+	"encoding/binary"
 	"encoding/hex"
 	"io/ioutil"
+	"os"
+	"sync"
 	"testing"
+
+	wasmtime "github.com/bytecodealliance/wasmtime-go"
+	"github.com/sporeframework/spore/db"
 )
 
 func Test_CreateWasmContract(t *testing.T) {
@@ -30,6 +36,11 @@ func Test_CreateWasmContract(t *testing.T) {
 		t.Error("Incorrect wasm contract digest")
 	}
 
+	deploymentGas, err := DeploymentGas(wasm)
+	if err != nil || deploymentGas != gas {
+		t.Errorf("incorrect deployment gas %d, expected %d", deploymentGas, gas)
+	}
+
 	t.Log(hex.EncodeToString(hash[:]), gas)
 }
 
@@ -54,12 +65,12 @@ func Test_Call(t *testing.T) {
 		t.Error("incorrect gas calculation")
 	}
 
-	result, gasCounter, err := eng.Call(hash, "increment")
+	result, gasCounter, err := eng.Call(hash, nil, 1000, EncodeCall("increment"))
 	if gasCounter != 619 {
 		t.Error("incorrect gas calculation")
 	}
 
-	resultValue := result.(int32)
+	resultValue := resultInt32(t, result)
 	if resultValue != 1 {
 		t.Error("Incorrect result from contract call returned")
 	}
@@ -89,7 +100,7 @@ func Test_CallMany(t *testing.T) {
 		t.Error("incorrect gas calculation")
 	}
 
-	result, gasCounter, err := eng.Call(hash, "increment")
+	result, gasCounter, err := eng.Call(hash, nil, 1000, EncodeCall("increment"))
 	if err != nil {
 		t.Errorf("Error calling 'increment' function on Wasm contract: %s", err)
 	}
@@ -97,7 +108,7 @@ func Test_CallMany(t *testing.T) {
 		t.Error("incorrect gas calculation")
 	}
 
-	resultValue := result.(int32)
+	resultValue := resultInt32(t, result)
 	if resultValue != 1 {
 		t.Error("Incorrect result from contract call returned")
 	}
@@ -106,18 +117,321 @@ func Test_CallMany(t *testing.T) {
 
 	var i int32
 	for i = resultValue + 1; i < loops; i++ {
-		res, gasUsed, err := eng.Call(hash, "increment")
+		res, gasUsed, err := eng.Call(hash, nil, 1000, EncodeCall("increment"))
 		if err != nil {
 			t.Errorf("Error calling 'increment' function on Wasm contract: %s", err)
 		}
 		if gasUsed != 619 {
 			t.Errorf("Incorrect gas calculation at iteration %d", i)
 		}
-		if res.(int32) != i {
-			t.Errorf("Incorrect result from contract call returned, was %d, expected %d", res.(int32), i)
+		if resultInt32(t, res) != i {
+			t.Errorf("Incorrect result from contract call returned, was %d, expected %d", resultInt32(t, res), i)
 		}
 	}
 
 	t.Log(result, gasCounter)
 
 }
+
+// resultInt32 decodes the return data of a function returning an i32
+func resultInt32(t *testing.T, result []byte) int32 {
+	if len(result) != 4 {
+		t.Fatalf("Incorrect result length %d, expected 4", len(result))
+	}
+	return int32(binary.LittleEndian.Uint32(result))
+}
+
+func Test_PersistentState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spore-contract")
+	if err != nil {
+		t.Fatalf("Error creating database directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	database, err := db.NewBadgerDB(dir)
+	if err != nil {
+		t.Fatalf("Error opening database: %s", err)
+	}
+	defer database.Close()
+
+	eng, err := NewPersistentContractEngine(database, []byte("contracts"))
+	if err != nil {
+		t.Error("Error constructing Wasm Contract Engine")
+	}
+
+	wasm, err := ioutil.ReadFile("./increment.wasm")
+	if err != nil {
+		t.Errorf("Error opening wasm file: %s", err)
+	}
+
+	hash, _, err := eng.CreateWasmContract(wasm)
+	if err != nil {
+		t.Errorf("Error creating Wasm contract: %s", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, _, err = eng.Call(hash, nil, 1000, EncodeCall("increment"))
+		if err != nil {
+			t.Errorf("Error calling 'increment' function on Wasm contract: %s", err)
+		}
+	}
+
+	// a new engine on the same database acts as a restarted node
+	restarted, err := NewPersistentContractEngine(database, []byte("contracts"))
+	if err != nil {
+		t.Error("Error constructing Wasm Contract Engine")
+	}
+
+	_, _, err = restarted.CreateWasmContract(wasm)
+	if err == nil {
+		t.Error("Expected error creating a contract that already exists")
+	}
+
+	result, gasUsed, err := restarted.Call(hash, nil, 1000, EncodeCall("increment"))
+	if err != nil {
+		t.Errorf("Error calling 'increment' function on rehydrated Wasm contract: %s", err)
+	}
+	if gasUsed != 619 {
+		t.Error("incorrect gas calculation")
+	}
+	if resultInt32(t, result) != 4 {
+		t.Errorf("Incorrect result from rehydrated contract call returned, was %d, expected %d", resultInt32(t, result), 4)
+	}
+}
+
+func Test_CallOutOfGas(t *testing.T) {
+	eng, err := NewContractEngine()
+	if err != nil {
+		t.Error("Error constructing Wasm Contract Engine")
+	}
+
+	wasm, err := wasmtime.Wat2Wasm(`(module (func (export "spin") (loop (br 0))))`)
+	if err != nil {
+		t.Fatalf("Error compiling wat: %s", err)
+	}
+
+	hash, _, err := eng.CreateWasmContract(wasm)
+	if err != nil {
+		t.Fatalf("Error creating Wasm contract: %s", err)
+	}
+
+	_, gasUsed, err := eng.Call(hash, nil, 5000, EncodeCall("spin"))
+	if err != ErrOutOfGas {
+		t.Errorf("Expected out of gas error, got %v", err)
+	}
+	if gasUsed != 5000 {
+		t.Errorf("Incorrect gas used for out of gas call, was %d, expected %d", gasUsed, 5000)
+	}
+
+	// a limit below the cost of a single call also runs out of gas
+	wasm, err = ioutil.ReadFile("./increment.wasm")
+	if err != nil {
+		t.Errorf("Error opening wasm file: %s", err)
+	}
+
+	hash, _, err = eng.CreateWasmContract(wasm)
+	if err != nil {
+		t.Errorf("Error creating Wasm contract: %s", err)
+	}
+
+	_, gasUsed, err = eng.Call(hash, nil, 618, EncodeCall("increment"))
+	if err != ErrOutOfGas || gasUsed != 618 {
+		t.Errorf("Expected out of gas at limit 618, got %v with gas %d", err, gasUsed)
+	}
+
+	result, gasUsed, err := eng.Call(hash, nil, 619, EncodeCall("increment"))
+	if err != nil || gasUsed != 619 {
+		t.Errorf("Expected call within limit 619 to succeed, got %v with gas %d", err, gasUsed)
+	}
+	if resultInt32(t, result) != 1 {
+		t.Errorf("Incorrect result from contract call returned, was %d, expected %d", resultInt32(t, result), 1)
+	}
+}
+
+func Test_CallFailureDiscarded(t *testing.T) {
+	eng, err := NewContractEngine()
+	if err != nil {
+		t.Fatal("Error constructing Wasm Contract Engine")
+	}
+
+	wasm, err := wasmtime.Wat2Wasm(`(module
+		(global $counter (export "counter") (mut i32) (i32.const 0))
+		(func (export "bump") (result i32)
+			(global.set $counter (i32.add (global.get $counter) (i32.const 1)))
+			(global.get $counter))
+		(func (export "fail")
+			(global.set $counter (i32.const 100))
+			unreachable))`)
+	if err != nil {
+		t.Fatalf("Error compiling wat: %s", err)
+	}
+
+	hash, _, err := eng.CreateWasmContract(wasm)
+	if err != nil {
+		t.Fatalf("Error creating Wasm contract: %s", err)
+	}
+	if _, _, err = eng.Call(hash, nil, 10000, EncodeCall("bump")); err != nil {
+		t.Fatalf("Error calling 'bump' function on Wasm contract: %s", err)
+	}
+	if _, _, err = eng.Call(hash, nil, 10000, EncodeCall("fail")); err == nil {
+		t.Fatal("Expected 'fail' function to fail")
+	}
+
+	// the instance the failed call modified is replaced by one with the last
+	// committed state, without a database too
+	result, _, err := eng.Call(hash, nil, 10000, EncodeCall("bump"))
+	if err != nil {
+		t.Fatalf("Error calling 'bump' function after a failed call: %s", err)
+	}
+	if resultInt32(t, result) != 2 {
+		t.Errorf("Incorrect result after a failed call, was %d, expected %d", resultInt32(t, result), 2)
+	}
+}
+
+func Test_Query(t *testing.T) {
+	eng, err := NewContractEngine()
+	if err != nil {
+		t.Error("Error constructing Wasm Contract Engine")
+	}
+
+	wasm, err := ioutil.ReadFile("./increment.wasm")
+	if err != nil {
+		t.Errorf("Error opening wasm file: %s", err)
+	}
+
+	hash, _, err := eng.CreateWasmContract(wasm)
+	if err != nil {
+		t.Errorf("Error creating Wasm contract: %s", err)
+	}
+
+	_, _, err = eng.Call(hash, nil, 1000, EncodeCall("increment"))
+	if err != nil {
+		t.Errorf("Error calling 'increment' function on Wasm contract: %s", err)
+	}
+
+	// queries see the current state but do not change it
+	for i := 0; i < 3; i++ {
+		result, gasUsed, err := eng.Query(hash, nil, 1000, EncodeCall("increment"))
+		if err != nil {
+			t.Errorf("Error querying 'increment' function on Wasm contract: %s", err)
+		}
+		if gasUsed != 619 {
+			t.Errorf("Incorrect gas calculation for query, was %d, expected %d", gasUsed, 619)
+		}
+		if resultInt32(t, result) != 2 {
+			t.Errorf("Incorrect result from contract query returned, was %d, expected %d", resultInt32(t, result), 2)
+		}
+	}
+
+	// queries run in a throwaway store, freed with their instance
+	forked, err := eng.fork(eng.contracts[hash])
+	if err != nil || forked.store == eng.contracts[hash].store {
+		t.Errorf("Expected query instance in a store of its own, got %v", err)
+	}
+
+	result, _, err := eng.Call(hash, nil, 1000, EncodeCall("increment"))
+	if err != nil {
+		t.Errorf("Error calling 'increment' function on Wasm contract: %s", err)
+	}
+	if resultInt32(t, result) != 2 {
+		t.Errorf("Incorrect result from contract call returned, was %d, expected %d", resultInt32(t, result), 2)
+	}
+}
+
+func Test_QueryConcurrent(t *testing.T) {
+	eng, err := NewContractEngine()
+	if err != nil {
+		t.Error("Error constructing Wasm Contract Engine")
+	}
+
+	wasm, err := ioutil.ReadFile("./increment.wasm")
+	if err != nil {
+		t.Errorf("Error opening wasm file: %s", err)
+	}
+
+	hash, _, err := eng.CreateWasmContract(wasm)
+	if err != nil {
+		t.Errorf("Error creating Wasm contract: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, _, err := eng.Query(hash, nil, 1000, EncodeCall("increment"))
+				if err != nil {
+					t.Errorf("Error querying 'increment' function on Wasm contract: %s", err)
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		_, _, err := eng.Call(hash, nil, 1000, EncodeCall("increment"))
+		if err != nil {
+			t.Errorf("Error calling 'increment' function on Wasm contract: %s", err)
+		}
+	}
+	wg.Wait()
+
+	result, _, err := eng.Call(hash, nil, 1000, EncodeCall("increment"))
+	if err != nil || resultInt32(t, result) != 101 {
+		t.Errorf("Expected 101 calls to be counted, got %v with error %v", result, err)
+	}
+}
+
+func Test_RehydratedGas(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spore-contract")
+	if err != nil {
+		t.Fatalf("Error creating database directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	database, err := db.NewBadgerDB(dir)
+	if err != nil {
+		t.Fatalf("Error opening database: %s", err)
+	}
+	defer database.Close()
+
+	// the start function of the contract uses gas
+	wasm, err := wasmtime.Wat2Wasm(`
+(module
+  (global $count (mut i32) (i32.const 0))
+  (func $init
+    (loop
+      (global.set $count (i32.add (global.get $count) (i32.const 1)))
+      (br_if 0 (i32.lt_u (global.get $count) (i32.const 10)))))
+  (start $init)
+  (func (export "count") (result i32) (global.get $count)))`)
+	if err != nil {
+		t.Fatalf("Error compiling wat: %s", err)
+	}
+
+	warm, err := NewPersistentContractEngine(database, []byte("contracts"))
+	if err != nil {
+		t.Fatal("Error constructing Wasm Contract Engine")
+	}
+	hash, _, err := warm.CreateWasmContract(wasm)
+	if err != nil {
+		t.Fatalf("Error creating Wasm contract: %s", err)
+	}
+	_, warmGas, err := warm.Call(hash, nil, 1000, EncodeCall("count"))
+	if err != nil {
+		t.Fatalf("Error calling 'count' function on Wasm contract: %s", err)
+	}
+
+	// a node that restarted loads the contract during the same call
+	cold, err := NewPersistentContractEngine(database, []byte("contracts"))
+	if err != nil {
+		t.Fatal("Error constructing Wasm Contract Engine")
+	}
+	_, coldGas, err := cold.Call(hash, nil, 1000, EncodeCall("count"))
+	if err != nil {
+		t.Fatalf("Error calling 'count' function on rehydrated Wasm contract: %s", err)
+	}
+	if coldGas != warmGas {
+		t.Errorf("Expected the same gas on a cold node, was %d, expected %d", coldGas, warmGas)
+	}
+}
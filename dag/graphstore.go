@@ -0,0 +1,235 @@
+package dag
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/sporeframework/spore/db"
+)
+
+// graphStoreCountKey holds the number of node records written to the store
+var graphStoreCountKey = []byte("count")
+
+// NodeRecord is the persisted form of a node in a GreedyGraphMem.
+// Parents are the edges of the node; the remaining fields are the derived
+// coloring and ordering state at the time the node was added.
+type NodeRecord struct {
+	Id             string   `json:"id"`
+	Parents        []string `json:"parents"`
+	Height         int      `json:"height"`
+	ColoringParent string   `json:"coloringParent,omitempty"`
+	BlueCount      int      `json:"blueCount"`
+	SelfOrder      int      `json:"selfOrder"`
+}
+
+// nodeRecordJSON is the encoding of a NodeRecord. Node ids may be any bytes,
+// such as transaction hashes, which JSON strings would not keep intact.
+type nodeRecordJSON struct {
+	Id             []byte   `json:"id"`
+	Parents        [][]byte `json:"parents"`
+	Height         int      `json:"height"`
+	ColoringParent []byte   `json:"coloringParent,omitempty"`
+	BlueCount      int      `json:"blueCount"`
+	SelfOrder      int      `json:"selfOrder"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (r *NodeRecord) MarshalJSON() ([]byte, error) {
+	stored := nodeRecordJSON{
+		Id:        []byte(r.Id),
+		Height:    r.Height,
+		BlueCount: r.BlueCount,
+		SelfOrder: r.SelfOrder,
+	}
+	if r.Parents != nil {
+		stored.Parents = make([][]byte, len(r.Parents))
+		for i, p := range r.Parents {
+			stored.Parents[i] = []byte(p)
+		}
+	}
+	if r.ColoringParent != "" {
+		stored.ColoringParent = []byte(r.ColoringParent)
+	}
+	return json.Marshal(stored)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (r *NodeRecord) UnmarshalJSON(data []byte) error {
+	var stored nodeRecordJSON
+	err := json.Unmarshal(data, &stored)
+	if err != nil {
+		return err
+	}
+
+	*r = NodeRecord{
+		Id:             string(stored.Id),
+		Height:         stored.Height,
+		ColoringParent: string(stored.ColoringParent),
+		BlueCount:      stored.BlueCount,
+		SelfOrder:      stored.SelfOrder,
+	}
+	if stored.Parents != nil {
+		r.Parents = make([]string, len(stored.Parents))
+		for i, p := range stored.Parents {
+			r.Parents[i] = string(p)
+		}
+	}
+	return nil
+}
+
+// GraphStore writes the nodes of a GreedyGraphMem through a db.DB as they are
+// added, and rebuilds the graph from those records on startup.
+//
+// Records are keyed by insertion sequence, so that replaying them in sequence
+// reproduces the same coloring, blue counts and order as the original graph.
+type GraphStore struct {
+	db        db.DB
+	namespace []byte
+	count     uint64
+	mu        sync.Mutex
+}
+
+// NewGraphStore returns a GraphStore that keeps its records in the given
+// namespace of the database.
+func NewGraphStore(database db.DB, namespace []byte) (*GraphStore, error) {
+	s := &GraphStore{
+		db:        database,
+		namespace: namespace,
+	}
+
+	ok, err := database.Has(s.countNamespace(), graphStoreCountKey)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		countBytes, err := database.Get(s.countNamespace(), graphStoreCountKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(countBytes) != 8 {
+			return nil, fmt.Errorf("invalid graph store node count of %d bytes", len(countBytes))
+		}
+		s.count = binary.BigEndian.Uint64(countBytes)
+	}
+
+	return s, nil
+}
+
+// Count returns the number of node records in the store
+func (s *GraphStore) Count() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.count
+}
+
+// Save writes the record of node id, as currently held by g, to the store.
+// It should be called once for each node, right after it was added to g.
+func (s *GraphStore) Save(g *GreedyGraphMem, id string) error {
+	record, err := g.Record(id)
+	if err != nil {
+		return err
+	}
+
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode node %s: %s", id, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.db.Set(s.namespace, sequenceKey(s.count), recordBytes)
+	if err != nil {
+		return fmt.Errorf("failed to store node %s: %s", id, err)
+	}
+
+	countBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(countBytes, s.count+1)
+	err = s.db.Set(s.countNamespace(), graphStoreCountKey, countBytes)
+	if err != nil {
+		return fmt.Errorf("failed to store node count after node %s: %s", id, err)
+	}
+	s.count++
+
+	return nil
+}
+
+// Load rebuilds a GreedyGraphMem with the given k from the records in the store.
+// Nodes are re-added in the order they were saved, and the resulting coloring
+// state of every node is checked against its stored record.
+func (s *GraphStore) Load(k int) (*GreedyGraphMem, error) {
+	g, err := NewGreedyGraphMem(k)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := uint64(0); i < s.count; i++ {
+		recordBytes, err := s.db.Get(s.namespace, sequenceKey(i))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read node record %d: %s", i, err)
+		}
+
+		var stored NodeRecord
+		err = json.Unmarshal(recordBytes, &stored)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode node record %d: %s", i, err)
+		}
+
+		parents := make([]string, len(stored.Parents))
+		copy(parents, stored.Parents)
+		_, err = g.Add(stored.Id, parents)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add node %s: %s", stored.Id, err)
+		}
+
+		rebuilt, err := g.Record(stored.Id)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(rebuilt, &stored) {
+			return nil, fmt.Errorf("rebuilt node %s does not match stored record: %+v != %+v", stored.Id, rebuilt, stored)
+		}
+	}
+
+	return g, nil
+}
+
+func (s *GraphStore) countNamespace() []byte {
+	return append(append([]byte{}, s.namespace...), []byte("-meta")...)
+}
+
+// sequenceKey returns the big-endian key of the i-th node record
+func sequenceKey(i uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, i)
+	return key
+}
+
+// Record returns the persistable record of a node
+func (g *GreedyGraphMem) Record(id string) (*NodeRecord, error) {
+	parents, err := g.Parents(id)
+	if err != nil {
+		return nil, err
+	}
+
+	height, err := g.Height(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NodeRecord{
+		Id:             id,
+		Parents:        parents,
+		Height:         height,
+		ColoringParent: g.coloringParents[id],
+		BlueCount:      g.blueCount[id],
+		SelfOrder:      g.selfOrder[id],
+	}, nil
+}
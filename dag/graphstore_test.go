@@ -0,0 +1,155 @@
+package dag
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// memDB is a map-backed db.DB used to exercise GraphStore
+type memDB struct {
+	values map[string][]byte
+}
+
+func newMemDB() *memDB {
+	return &memDB{values: make(map[string][]byte)}
+}
+
+func (m *memDB) Get(namespace, key []byte) ([]byte, error) {
+	value, ok := m.values[string(namespace)+"/"+string(key)]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+	return value, nil
+}
+
+func (m *memDB) Set(namespace, key, value []byte) error {
+	m.values[string(namespace)+"/"+string(key)] = value
+	return nil
+}
+
+func (m *memDB) Has(namespace, key []byte) (bool, error) {
+	_, ok := m.values[string(namespace)+"/"+string(key)]
+	return ok, nil
+}
+
+func (m *memDB) Delete(namespace, key []byte) error {
+	delete(m.values, string(namespace)+"/"+string(key))
+	return nil
+}
+
+func (m *memDB) Iterate(namespace, prefix []byte, fn func(key, value []byte) error) error {
+	scanPrefix := string(namespace) + "/" + string(prefix)
+	var keys []string
+	for key := range m.values {
+		if strings.HasPrefix(key, scanPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		err := fn([]byte(key[len(namespace)+1:]), m.values[key])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memDB) Close() error {
+	return nil
+}
+
+func TestGraphStore_SaveLoad(t *testing.T) {
+	database := newMemDB()
+	store, err := NewGraphStore(database, []byte("dag"))
+	if err != nil {
+		t.Fatalf("failed to create graph store: %s", err)
+	}
+
+	g, _ := NewGreedyGraphMem(3)
+	err = genPhantomFig4GreedyGraphMem(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Save nodes in the same sequence they were added in
+	for _, id := range []string{"GENESIS", "B", "C", "D", "E", "F", "H", "I", "J", "K",
+		"L", "M", "N", "O", "P", "Q", "R", "S", "T", "U"} {
+		err = store.Save(g, id)
+		if err != nil {
+			t.Fatalf("failed to save node %s: %s", id, err)
+		}
+	}
+
+	// Reopen the store as a restarted node would
+	reopened, err := NewGraphStore(database, []byte("dag"))
+	if err != nil {
+		t.Fatalf("failed to reopen graph store: %s", err)
+	}
+	if reopened.Count() != 20 {
+		t.Errorf("wrong node count in reopened store. Expecting %d, got %d", 20, reopened.Count())
+	}
+
+	loaded, err := reopened.Load(3)
+	if err != nil {
+		t.Fatalf("failed to load graph: %s", err)
+	}
+
+	expectedTips, _ := g.Tips()
+	tips, _ := loaded.Tips()
+	if !reflect.DeepEqual(tips, expectedTips) {
+		t.Errorf("wrong tips after load. Expecting %v, got %v", expectedTips, tips)
+	}
+
+	expectedOrder, _ := g.Order()
+	order, _ := loaded.Order()
+	if !reflect.DeepEqual(order, expectedOrder) {
+		t.Errorf("wrong order after load. Expecting %v, got %v", expectedOrder, order)
+	}
+}
+
+func TestGraphStore_LoadEmpty(t *testing.T) {
+	store, err := NewGraphStore(newMemDB(), []byte("dag"))
+	if err != nil {
+		t.Fatalf("failed to create graph store: %s", err)
+	}
+
+	g, err := store.Load(3)
+	if err != nil {
+		t.Fatalf("failed to load empty graph: %s", err)
+	}
+
+	if len(g.Nodes()) != 0 {
+		t.Errorf("expected empty graph, got %d nodes", len(g.Nodes()))
+	}
+}
+
+func TestGraphStore_BinaryIds(t *testing.T) {
+	database := newMemDB()
+	store, _ := NewGraphStore(database, []byte("dag"))
+
+	// node ids are transaction hashes, which are not valid UTF-8
+	genesis, child := "\xff\x00\xfe genesis", "\x80\x81 child"
+	g, _ := NewGreedyGraphMem(3)
+	g.Add(genesis, nil)
+	g.Add(child, []string{genesis})
+	for _, id := range []string{genesis, child} {
+		if err := store.Save(g, id); err != nil {
+			t.Fatalf("failed to save node %x: %s", id, err)
+		}
+	}
+
+	loaded, err := store.Load(3)
+	if err != nil {
+		t.Fatalf("failed to load graph: %s", err)
+	}
+	for _, id := range []string{genesis, child} {
+		if ok, _ := loaded.NodeExists(id); !ok {
+			t.Errorf("expected node %x after load", id)
+		}
+	}
+}
@@ -353,6 +353,42 @@ func (g *GreedyGraphMem) TipDiff(subTips []string) ([]string, error) {
 	return diff, nil
 }
 
+// GetMissingNodes returns the nodes missing from a graph with the given subTips,
+// sorted topologically by height (ties broken by id), so that every node comes
+// after its parents. Like Graph.GetMissingNodes, subTips that are not in this
+// graph are ignored.
+func (g *GreedyGraphMem) GetMissingNodes(subTips []string) ([]string, error) {
+	knownTips := make([]string, 0, len(subTips))
+	for _, tip := range subTips {
+		if _, ok := g.parents[tip]; ok {
+			knownTips = append(knownTips, tip)
+		}
+	}
+
+	missing, err := g.TipDiff(knownTips)
+	if err != nil {
+		return nil, err
+	}
+
+	heights := make(map[string]int, len(missing))
+	for _, id := range missing {
+		height, err := g.Height(id)
+		if err != nil {
+			return nil, err
+		}
+		heights[id] = height
+	}
+
+	sort.Slice(missing, func(i, j int) bool {
+		if heights[missing[i]] != heights[missing[j]] {
+			return heights[missing[i]] < heights[missing[j]]
+		}
+		return missing[i] < missing[j]
+	})
+
+	return missing, nil
+}
+
 // Parents returns the parents of the node
 func (g *GreedyGraphMem) Parents(id string) ([]string, error) {
 	parents, ok := g.parents[id]
@@ -511,6 +547,22 @@ func (g *GreedyGraphMem) Order() ([]string, error) {
 	return order, nil
 }
 
+// ColoringTip returns the coloring tip of the graph, the bluest of its tips.
+// Returns an empty string if the graph is empty.
+func (g *GreedyGraphMem) ColoringTip() string {
+	return g.coloringTip
+}
+
+// BlueScore returns the number of blue nodes in the past of the node
+func (g *GreedyGraphMem) BlueScore(id string) (int, error) {
+	blueCount, ok := g.blueCount[id]
+	if !ok {
+		return -1, fmt.Errorf("missing node %s", id)
+	}
+
+	return blueCount, nil
+}
+
 // ColoringOrder returns the coloring order of the graph
 func (g *GreedyGraphMem) ColoringOrder() (*OrderedStringSet, error) {
 	return g.coloringOrder.Keys(), nil
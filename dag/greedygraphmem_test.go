@@ -494,6 +494,41 @@ func TestGreedyGraphMem_TipDiff(t *testing.T) {
 	}
 }
 
+func TestGreedyGraphMem_GetMissingNodes(t *testing.T) {
+	var k = 3
+	g, err := NewGreedyGraphMem(k)
+	if err != nil {
+		t.Errorf("failed to create new GreedyGraphMem: %s", err)
+	}
+
+	err = genPhantomFig3GreedyGraphMem(g)
+	if err != nil {
+		t.Errorf("failed to generate phantom fig3: %s", err)
+	}
+
+	// Z is not in the graph, and is ignored
+	subTips := []string{"H", "I", "Z"}
+	missing, err := g.GetMissingNodes(subTips)
+	if err != nil {
+		t.Errorf("failed to get missing nodes for %s: %s", subTips, err)
+	}
+
+	expected := []string{"B", "F", "J", "K", "L", "M"}
+	if !reflect.DeepEqual(missing, expected) {
+		t.Errorf("wrong missing nodes for %s; got %s, want %s", subTips, missing, expected)
+	}
+
+	// An empty graph is missing every node, parents first
+	missing, err = g.GetMissingNodes([]string{})
+	if err != nil {
+		t.Errorf("failed to get missing nodes for empty graph: %s", err)
+	}
+
+	if len(missing) != 12 || missing[0] != "GENESIS" || missing[11] != "M" {
+		t.Errorf("wrong missing nodes for empty graph; got %s", missing)
+	}
+}
+
 func TestGreedyGraphMem_Parents(t *testing.T) {
 	var k = 3
 	g, err := NewGreedyGraphMem(k)
@@ -571,6 +606,51 @@ func TestGreedyGraphMem_Height(t *testing.T) {
 	}
 }
 
+func TestGreedyGraphMem_BlueScore(t *testing.T) {
+	var k = 3
+	g, err := NewGreedyGraphMem(k)
+	if err != nil {
+		t.Errorf("failed to create new GreedyGraphMem: %s", err)
+	}
+
+	err = genPhantomFig3GreedyGraphMem(g)
+	if err != nil {
+		t.Errorf("failed to generate phantom paper figure 3: %s", err)
+	}
+
+	tip := g.ColoringTip()
+	if tip != "M" {
+		t.Errorf("wrong coloring tip; got %s, want %s", tip, "M")
+	}
+
+	tests := []struct {
+		node  string
+		score int
+	}{
+		{"GENESIS", 0},
+		{"B", 1},
+		{"L", 4},
+		{"K", 5},
+		{"M", 6},
+	}
+
+	for _, test := range tests {
+		score, err := g.BlueScore(test.node)
+		if err != nil {
+			t.Errorf("failed to get blue score for node %s: %s", test.node, err)
+		}
+
+		if score != test.score {
+			t.Errorf("wrong blue score for node %s; got %d, want %d", test.node, score, test.score)
+		}
+	}
+
+	_, err = g.BlueScore("Z")
+	if err == nil {
+		t.Errorf("expected error for blue score of missing node")
+	}
+}
+
 func TestGreedyGraphMem_Order_Fig3(t *testing.T) {
 	var k = 3
 	g, err := NewGreedyGraphMem(k)
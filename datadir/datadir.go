@@ -0,0 +1,90 @@
+// Package datadir resolves the data directory of a node, which roots its
+// configuration, keystore and database, and locks it so that a single node
+// process uses it at a time.
+package datadir
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/kirsle/configdir"
+)
+
+// Env is the environment variable naming the data directory, used when no
+// directory is given on the command line
+const Env = "SPORE_HOME"
+
+// LockFile is the file of the data directory holding its lock
+const LockFile = "LOCK"
+
+// ErrLocked is returned when the data directory is used by another process
+var ErrLocked = errors.New("data directory is in use by another process")
+
+// Resolve returns the data directory: dir if set, else $SPORE_HOME, else the
+// spore directory of the local config directory
+func Resolve(dir string) string {
+	if dir == "" {
+		dir = os.Getenv(Env)
+	}
+	if dir == "" {
+		dir = configdir.LocalConfig("spore")
+	}
+	return dir
+}
+
+// Path returns p, relative to the data directory home unless it is absolute
+func Path(home, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(home, p)
+}
+
+// Lock is the lock of a data directory
+type Lock struct {
+	file *os.File
+}
+
+// Acquire creates the data directory if needed and locks it. It fails with
+// ErrLocked if another process holds the lock. The lock is released by
+// Release or when the process exits.
+func Acquire(home string) (*Lock, error) {
+	if err := os.MkdirAll(home, 0700); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filepath.Join(home, LockFile), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err = lockFile(file); err != nil {
+		file.Close()
+		if err == errWouldBlock {
+			return nil, fmt.Errorf("%s: %w", home, ErrLocked)
+		}
+		return nil, err
+	}
+
+	// the pid of the holder helps finding it, it is not used for locking
+	if err = file.Truncate(0); err == nil {
+		_, err = io.WriteString(file, strconv.Itoa(os.Getpid())+"\n")
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &Lock{file: file}, nil
+}
+
+// Release releases the lock
+func (l *Lock) Release() error {
+	if err := unlockFile(l.file); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
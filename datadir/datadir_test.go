@@ -0,0 +1,58 @@
+package datadir
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	os.Setenv(Env, "/tmp/spore-home")
+	defer os.Unsetenv(Env)
+
+	if dir := Resolve("/tmp/datadir"); dir != "/tmp/datadir" {
+		t.Errorf("expected the given directory, got %s", dir)
+	}
+	if dir := Resolve(""); dir != "/tmp/spore-home" {
+		t.Errorf("expected the directory of %s, got %s", Env, dir)
+	}
+	os.Unsetenv(Env)
+	if dir := Resolve(""); dir == "" {
+		t.Error("expected the default directory")
+	}
+
+	if p := Path("/home", "db"); p != filepath.Join("/home", "db") {
+		t.Errorf("expected a path in the data directory, got %s", p)
+	}
+	if p := Path("/home", "/var/db"); p != "/var/db" {
+		t.Errorf("expected an absolute path to be kept, got %s", p)
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	dir, err := ioutil.TempDir("", "datadir")
+	if err != nil {
+		t.Fatalf("failed to create directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	home := filepath.Join(dir, "node")
+
+	lock, err := Acquire(home)
+	if err != nil {
+		t.Fatalf("failed to lock data directory: %s", err)
+	}
+	if _, err = Acquire(home); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected the data directory to be locked, got %v", err)
+	}
+
+	if err = lock.Release(); err != nil {
+		t.Fatalf("failed to release lock: %s", err)
+	}
+	lock, err = Acquire(home)
+	if err != nil {
+		t.Fatalf("expected a released data directory to be lockable, got %s", err)
+	}
+	lock.Release()
+}
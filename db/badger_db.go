@@ -30,6 +30,8 @@ type (
 		Get(namespace, key []byte) (value []byte, err error)
 		Set(namespace, key, value []byte) error
 		Has(namespace, key []byte) (bool, error)
+		Delete(namespace, key []byte) error
+		Iterate(namespace, prefix []byte, fn func(key, value []byte) error) error
 		Close() error
 	}
 
@@ -119,6 +121,47 @@ func (bdb *BadgerDB) Has(namespace, key []byte) (ok bool, err error) {
 	return
 }
 
+// Delete implements the DB interface. It removes a key from a namespace.
+// Deleting a key that does not exist is not an error.
+func (bdb *BadgerDB) Delete(namespace, key []byte) error {
+	err := bdb.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(badgerNamespaceKey(namespace, key))
+	})
+
+	if err != nil {
+		bdb.db.Opts().Logger.Errorf("failed to delete key %s for namespace %s: %v", key, namespace, err)
+		return err
+	}
+
+	return nil
+}
+
+// Iterate implements the DB interface. It calls fn with the keys of a namespace
+// starting with prefix, without the namespace, and their values in key order,
+// until fn returns an error, which is then returned.
+func (bdb *BadgerDB) Iterate(namespace, prefix []byte, fn func(key, value []byte) error) error {
+	namespaceLen := len(badgerNamespaceKey(namespace, nil))
+	scanPrefix := badgerNamespaceKey(namespace, prefix)
+
+	return bdb.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(scanPrefix); it.ValidForPrefix(scanPrefix); it.Next() {
+			item := it.Item()
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			err = fn(item.KeyCopy(nil)[namespaceLen:], value)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // Close implements the DB interface. It closes the connection to the underlying
 // BadgerDB database as well as invoking the context's cancel function.
 func (bdb *BadgerDB) Close() error {
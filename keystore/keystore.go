@@ -0,0 +1,378 @@
+// Package keystore stores account keys and the libp2p identity of the node
+// encrypted with a passphrase, using scrypt and AES-128-CTR. Account keys are
+// Ethereum keystore v3 files, so they can be exchanged with Ethereum wallets.
+// The node identity uses the same encryption in a file of its own.
+//
+// The files of a keystore directory are
+//
+//	accounts/<name>.json    account keys, by name
+//	node.json               the node identity
+//	node-<unix time>.json   node identities replaced by RotateNodeIdentity,
+//	                        with a -<n> suffix after the first of a second
+//
+// All files are written with mode 0600 in directories with mode 0700.
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	ethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	libp2pcrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+var (
+	// ErrNotFound is returned for keys that are not in the keystore
+	ErrNotFound = errors.New("key not found")
+
+	// ErrExists is returned when a key would overwrite another
+	ErrExists = errors.New("key already exists")
+
+	// ErrDecrypt is returned when a key cannot be decrypted with a passphrase
+	ErrDecrypt = ethkeystore.ErrDecrypt
+
+	validName = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+)
+
+const (
+	accountsDir = "accounts"
+	nodeFile    = "node.json"
+	nodeKeyType = "libp2p"
+	nodeVersion = 3
+	keyFileMode = 0600
+	keyDirMode  = 0700
+)
+
+// KeyStore is a directory of encrypted keys
+type KeyStore struct {
+	dir     string
+	scryptN int
+	scryptP int
+}
+
+// New returns the keystore in dir, creating the directory if needed
+func New(dir string) (*KeyStore, error) {
+	return newKeyStore(dir, ethkeystore.StandardScryptN, ethkeystore.StandardScryptP)
+}
+
+// NewLight returns a keystore that encrypts keys with lighter scrypt
+// parameters, faster to unlock but weaker against brute force
+func NewLight(dir string) (*KeyStore, error) {
+	return newKeyStore(dir, ethkeystore.LightScryptN, ethkeystore.LightScryptP)
+}
+
+func newKeyStore(dir string, scryptN, scryptP int) (*KeyStore, error) {
+	err := os.MkdirAll(filepath.Join(dir, accountsDir), keyDirMode)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyStore{dir: dir, scryptN: scryptN, scryptP: scryptP}, nil
+}
+
+// Dir returns the directory of the keystore
+func (ks *KeyStore) Dir() string {
+	return ks.dir
+}
+
+func (ks *KeyStore) accountFile(name string) (string, error) {
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("invalid key name %q: only letters, digits, '_', '.' and '-' are allowed", name)
+	}
+	return filepath.Join(ks.dir, accountsDir, name+".json"), nil
+}
+
+// Accounts returns the names of the account keys
+func (ks *KeyStore) Accounts() ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(ks.dir, accountsDir))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), ".json") {
+			names = append(names, strings.TrimSuffix(f.Name(), ".json"))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// NewAccount generates an account key and stores it under name
+func (ks *KeyStore) NewAccount(name, passphrase string) (*ecdsa.PrivateKey, error) {
+	prv, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return prv, ks.ImportAccount(name, prv, passphrase)
+}
+
+// ImportAccount stores an account key under name
+func (ks *KeyStore) ImportAccount(name string, prv *ecdsa.PrivateKey, passphrase string) error {
+	key := &ethkeystore.Key{
+		Address:    crypto.PubkeyToAddress(prv.PublicKey),
+		PrivateKey: prv,
+	}
+	if err := randomUUID(key.Id[:]); err != nil {
+		return err
+	}
+
+	keyJSON, err := ethkeystore.EncryptKey(key, passphrase, ks.scryptN, ks.scryptP)
+	if err != nil {
+		return err
+	}
+	return ks.writeAccount(name, keyJSON)
+}
+
+// ImportAccountJSON stores a keystore v3 file under name, after checking that
+// it decrypts with the passphrase
+func (ks *KeyStore) ImportAccountJSON(name string, keyJSON []byte, passphrase string) error {
+	if _, err := ethkeystore.DecryptKey(keyJSON, passphrase); err != nil {
+		return err
+	}
+	return ks.writeAccount(name, keyJSON)
+}
+
+// ExportAccountJSON returns the keystore v3 file of an account key. The key
+// stays encrypted with its passphrase.
+func (ks *KeyStore) ExportAccountJSON(name string) ([]byte, error) {
+	file, err := ks.accountFile(name)
+	if err != nil {
+		return nil, err
+	}
+	keyJSON, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("account %q: %w", name, ErrNotFound)
+	}
+	return keyJSON, err
+}
+
+// Account decrypts the account key stored under name
+func (ks *KeyStore) Account(name, passphrase string) (*ecdsa.PrivateKey, error) {
+	keyJSON, err := ks.ExportAccountJSON(name)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ethkeystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("account %q: %w", name, err)
+	}
+	return key.PrivateKey, nil
+}
+
+// DeleteAccount removes the account key stored under name
+func (ks *KeyStore) DeleteAccount(name string) error {
+	file, err := ks.accountFile(name)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(file)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("account %q: %w", name, ErrNotFound)
+	}
+	return err
+}
+
+func (ks *KeyStore) writeAccount(name string, keyJSON []byte) error {
+	file, err := ks.accountFile(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(file); err == nil {
+		return fmt.Errorf("account %q: %w", name, ErrExists)
+	}
+	return writeKeyFile(file, keyJSON)
+}
+
+// nodeKeyJSON is the file of a node identity: a marshaled libp2p private key
+// encrypted like the keys of keystore v3 files
+type nodeKeyJSON struct {
+	Type    string                 `json:"type"`
+	PeerID  string                 `json:"peerId"`
+	Crypto  ethkeystore.CryptoJSON `json:"crypto"`
+	Version int                    `json:"version"`
+}
+
+// HasNodeIdentity reports whether the keystore holds a node identity
+func (ks *KeyStore) HasNodeIdentity() bool {
+	_, err := os.Stat(filepath.Join(ks.dir, nodeFile))
+	return err == nil
+}
+
+// NodeIdentity decrypts the node identity
+func (ks *KeyStore) NodeIdentity(passphrase string) (libp2pcrypto.PrivKey, error) {
+	data, err := ioutil.ReadFile(filepath.Join(ks.dir, nodeFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("node identity: %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nodeKey := &nodeKeyJSON{}
+	if err = json.Unmarshal(data, nodeKey); err != nil {
+		return nil, err
+	}
+	if nodeKey.Type != nodeKeyType || nodeKey.Version != nodeVersion {
+		return nil, fmt.Errorf("unsupported node identity of type %q version %d", nodeKey.Type, nodeKey.Version)
+	}
+
+	keyBytes, err := ethkeystore.DecryptDataV3(nodeKey.Crypto, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("node identity: %w", err)
+	}
+	return libp2pcrypto.UnmarshalPrivateKey(keyBytes)
+}
+
+// NewNodeIdentity generates an ECDSA node identity and stores it. It fails if
+// the keystore already holds one.
+func (ks *KeyStore) NewNodeIdentity(passphrase string) (libp2pcrypto.PrivKey, error) {
+	prv, _, err := libp2pcrypto.GenerateECDSAKeyPair(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return prv, ks.ImportNodeIdentity(prv, passphrase)
+}
+
+// ImportNodeIdentity stores a node identity. It fails if the keystore already
+// holds one.
+func (ks *KeyStore) ImportNodeIdentity(prv libp2pcrypto.PrivKey, passphrase string) error {
+	if ks.HasNodeIdentity() {
+		return fmt.Errorf("node identity: %w", ErrExists)
+	}
+	return ks.writeNodeIdentity(prv, passphrase)
+}
+
+// RotateNodeIdentity replaces the node identity by a new one, keeping the
+// previous one as node-<unix time>.json. The node uses the new identity, and
+// so a new peer id, from its next start. node.json holds either identity at
+// any time, so that an interrupted rotation does not lose both.
+func (ks *KeyStore) RotateNodeIdentity(passphrase string) (libp2pcrypto.PrivKey, error) {
+	// the previous identity must unlock with the passphrase, so that a typo
+	// does not lock the operator out of the new one
+	if _, err := ks.NodeIdentity(passphrase); err != nil {
+		return nil, err
+	}
+
+	prv, _, err := libp2pcrypto.GenerateECDSAKeyPair(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ks.encodeNodeIdentity(prv, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := ioutil.ReadFile(filepath.Join(ks.dir, nodeFile))
+	if err != nil {
+		return nil, err
+	}
+	if err = writeKeyFile(ks.backupFile(), previous); err != nil {
+		return nil, err
+	}
+	return prv, writeKeyFile(filepath.Join(ks.dir, nodeFile), data)
+}
+
+// backupFile returns an unused name for a replaced node identity
+func (ks *KeyStore) backupFile() string {
+	name := fmt.Sprintf("node-%d", time.Now().Unix())
+	file := filepath.Join(ks.dir, name+".json")
+	for n := 1; ; n++ {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			return file
+		}
+		file = filepath.Join(ks.dir, fmt.Sprintf("%s-%d.json", name, n))
+	}
+}
+
+func (ks *KeyStore) writeNodeIdentity(prv libp2pcrypto.PrivKey, passphrase string) error {
+	data, err := ks.encodeNodeIdentity(prv, passphrase)
+	if err != nil {
+		return err
+	}
+	return writeKeyFile(filepath.Join(ks.dir, nodeFile), data)
+}
+
+// encodeNodeIdentity returns the key file of a node identity encrypted with
+// the passphrase
+func (ks *KeyStore) encodeNodeIdentity(prv libp2pcrypto.PrivKey, passphrase string) ([]byte, error) {
+	keyBytes, err := libp2pcrypto.MarshalPrivateKey(prv)
+	if err != nil {
+		return nil, err
+	}
+	peerID, err := peer.IDFromPrivateKey(prv)
+	if err != nil {
+		return nil, err
+	}
+
+	cryptoJSON, err := ethkeystore.EncryptDataV3(keyBytes, []byte(passphrase), ks.scryptN, ks.scryptP)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&nodeKeyJSON{
+		Type:    nodeKeyType,
+		PeerID:  peerID.Pretty(),
+		Crypto:  cryptoJSON,
+		Version: nodeVersion,
+	})
+}
+
+// writeKeyFile writes a key file readable only by its owner. The file is
+// written under a temporary name first, so that a crash does not leave a
+// truncated key behind.
+func writeKeyFile(file string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err = tmp.Chmod(keyFileMode); err == nil {
+		_, err = tmp.Write(data)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// randomUUID fills id with a random version 4 UUID
+func randomUUID(id []byte) error {
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+	return nil
+}
+
+// ReadLegacyKey reads a plaintext hex-encoded libp2p private key, as written
+// by previous versions of the node and the bootstrap node
+func ReadLegacyKey(file string) (libp2pcrypto.PrivKey, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := hex.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, err
+	}
+	return libp2pcrypto.UnmarshalPrivateKey(decoded)
+}
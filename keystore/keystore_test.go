@@ -0,0 +1,185 @@
+package keystore
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	libp2pcrypto "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// testKeyStore returns a keystore with very light scrypt parameters
+func testKeyStore(t *testing.T) *KeyStore {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatalf("failed to create directory: %s", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	ks, err := newKeyStore(dir, 2, 1)
+	if err != nil {
+		t.Fatalf("failed to create keystore: %s", err)
+	}
+	return ks
+}
+
+func TestAccount(t *testing.T) {
+	ks := testKeyStore(t)
+
+	prv, err := ks.NewAccount("alice", "secret")
+	if err != nil {
+		t.Fatalf("failed to create account: %s", err)
+	}
+
+	info, err := os.Stat(filepath.Join(ks.Dir(), accountsDir, "alice.json"))
+	if err != nil || info.Mode().Perm() != keyFileMode {
+		t.Errorf("expected key file with mode %o, got %v", keyFileMode, info)
+	}
+
+	unlocked, err := ks.Account("alice", "secret")
+	if err != nil || !unlocked.Equal(prv) {
+		t.Fatalf("expected the stored key, got %v", err)
+	}
+	if _, err = ks.Account("alice", "wrong"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("expected decryption error, got %v", err)
+	}
+	if _, err = ks.Account("bob", "secret"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	if _, err = ks.NewAccount("alice", "secret"); !errors.Is(err, ErrExists) {
+		t.Errorf("expected existing key not to be overwritten, got %v", err)
+	}
+	if _, err = ks.NewAccount("../alice", "secret"); err == nil {
+		t.Error("expected invalid name to be rejected")
+	}
+
+	names, err := ks.Accounts()
+	if err != nil || len(names) != 1 || names[0] != "alice" {
+		t.Errorf("expected a single account, got %v", names)
+	}
+}
+
+func TestAccount_ExportImport(t *testing.T) {
+	ks := testKeyStore(t)
+	prv, err := ks.NewAccount("alice", "secret")
+	if err != nil {
+		t.Fatalf("failed to create account: %s", err)
+	}
+
+	keyJSON, err := ks.ExportAccountJSON("alice")
+	if err != nil {
+		t.Fatalf("failed to export account: %s", err)
+	}
+
+	// exported keys are Ethereum keystore v3 files
+	key, err := ethkeystore.DecryptKey(keyJSON, "secret")
+	if err != nil || key.Address != crypto.PubkeyToAddress(prv.PublicKey) {
+		t.Fatalf("expected a keystore v3 file of the key, got %v", err)
+	}
+	var fields map[string]interface{}
+	json.Unmarshal(keyJSON, &fields)
+	if fields["version"] != float64(3) || fields["address"] == nil {
+		t.Errorf("expected version 3 file with an address, got %v", fields)
+	}
+
+	other := testKeyStore(t)
+	if err = other.ImportAccountJSON("alice", keyJSON, "wrong"); err == nil {
+		t.Error("expected import with the wrong passphrase to fail")
+	}
+	if err = other.ImportAccountJSON("alice", keyJSON, "secret"); err != nil {
+		t.Fatalf("failed to import account: %s", err)
+	}
+	if unlocked, err := other.Account("alice", "secret"); err != nil || !unlocked.Equal(prv) {
+		t.Errorf("expected the imported key, got %v", err)
+	}
+
+	if err = ks.DeleteAccount("alice"); err != nil {
+		t.Errorf("failed to delete account: %s", err)
+	}
+	if _, err = ks.ExportAccountJSON("alice"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected deleted account to be gone, got %v", err)
+	}
+}
+
+func TestNodeIdentity(t *testing.T) {
+	ks := testKeyStore(t)
+	if ks.HasNodeIdentity() {
+		t.Fatal("expected empty keystore")
+	}
+	if _, err := ks.NodeIdentity("secret"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	prv, err := ks.NewNodeIdentity("secret")
+	if err != nil {
+		t.Fatalf("failed to create node identity: %s", err)
+	}
+	unlocked, err := ks.NodeIdentity("secret")
+	if err != nil || !unlocked.Equals(prv) {
+		t.Fatalf("expected the stored identity, got %v", err)
+	}
+	if _, err = ks.NodeIdentity("wrong"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("expected decryption error, got %v", err)
+	}
+	if _, err = ks.NewNodeIdentity("secret"); !errors.Is(err, ErrExists) {
+		t.Errorf("expected existing identity not to be overwritten, got %v", err)
+	}
+
+	if _, err = ks.RotateNodeIdentity("wrong"); err == nil {
+		t.Error("expected rotation with the wrong passphrase to fail")
+	}
+	rotated, err := ks.RotateNodeIdentity("secret")
+	if err != nil {
+		t.Fatalf("failed to rotate node identity: %s", err)
+	}
+	if rotated.Equals(prv) {
+		t.Error("expected a new identity")
+	}
+	if unlocked, err = ks.NodeIdentity("secret"); err != nil || !unlocked.Equals(rotated) {
+		t.Errorf("expected the rotated identity, got %v", err)
+	}
+
+	// rotations within a second keep every previous identity
+	if _, err = ks.RotateNodeIdentity("secret"); err != nil {
+		t.Fatalf("failed to rotate node identity: %s", err)
+	}
+	old, _ := filepath.Glob(filepath.Join(ks.Dir(), "node-*.json"))
+	if len(old) != 2 {
+		t.Errorf("expected the previous identities to be kept, got %v", old)
+	}
+}
+
+func TestPassphrase_Empty(t *testing.T) {
+	defer os.Unsetenv(PassphraseEnv)
+	os.Setenv(PassphraseEnv, "")
+
+	if passphrase, err := Passphrase("", "", false); err != nil || passphrase != "" {
+		t.Errorf("expected the empty passphrase of existing keys, got %v", err)
+	}
+	if _, err := Passphrase("", "", true); err == nil {
+		t.Error("expected an empty passphrase to be rejected for new keys")
+	}
+}
+
+func TestReadLegacyKey(t *testing.T) {
+	ks := testKeyStore(t)
+	prv, _, err := libp2pcrypto.GenerateECDSAKeyPair(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	keyBytes, _ := libp2pcrypto.MarshalPrivateKey(prv)
+
+	file := filepath.Join(ks.Dir(), "key.txt")
+	ioutil.WriteFile(file, []byte(hex.EncodeToString(keyBytes)+"\n"), 0600)
+	legacy, err := ReadLegacyKey(file)
+	if err != nil || !legacy.Equals(prv) {
+		t.Errorf("expected the legacy key, got %v", err)
+	}
+}
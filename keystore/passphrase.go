@@ -0,0 +1,76 @@
+package keystore
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// PassphraseEnv is the environment variable read for the passphrase when no
+// passphrase file is given
+const PassphraseEnv = "SPORE_PASSPHRASE"
+
+// Passphrase returns the passphrase of the keystore, from the first of
+//
+//	the first line of file, if file is set
+//	the environment variable SPORE_PASSPHRASE, if it is set
+//	an interactive prompt, if stdin is a terminal
+//
+// With confirm, for new keys, the prompt asks for the passphrase twice, and an
+// empty passphrase is rejected wherever it comes from: a variable set but left
+// empty by mistake would otherwise encrypt the keys with nothing.
+func Passphrase(file, prompt string, confirm bool) (string, error) {
+	passphrase, err := readPassphrase(file, prompt, confirm)
+	if err == nil && confirm && passphrase == "" {
+		return "", errors.New("the passphrase of new keys must not be empty")
+	}
+	return passphrase, err
+}
+
+// readPassphrase implements Passphrase, without its check of new passphrases
+func readPassphrase(file, prompt string, confirm bool) (string, error) {
+	if file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase file: %s", err)
+		}
+		return strings.TrimRight(strings.SplitN(string(data), "\n", 2)[0], "\r"), nil
+	}
+
+	if passphrase, ok := os.LookupEnv(PassphraseEnv); ok {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return "", fmt.Errorf("no passphrase: use a passphrase file, set %s or run in a terminal", PassphraseEnv)
+	}
+
+	passphrase, err := readPassword(fd, prompt)
+	if err != nil || !confirm {
+		return passphrase, err
+	}
+	repeated, err := readPassword(fd, "Repeat the passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if repeated != passphrase {
+		return "", errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+func readPassword(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+
+	passphrase, err := terminal.ReadPassword(fd)
+	if err != nil {
+		return "", err
+	}
+	return string(passphrase), nil
+}
@@ -0,0 +1,55 @@
+package keystore
+
+import (
+	"fmt"
+	"os"
+
+	libp2pcrypto "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// UnlockNodeIdentity returns the node identity of the keystore, unlocked with
+// the passphrase of Passphrase. A keystore without an identity imports the
+// plaintext key of legacyFile if it exists, then removes that file, or else
+// generates a new identity.
+func UnlockNodeIdentity(ks *KeyStore, passphraseFile, legacyFile string) (libp2pcrypto.PrivKey, error) {
+	if ks.HasNodeIdentity() {
+		passphrase, err := Passphrase(passphraseFile, "Passphrase of the node identity: ", false)
+		if err != nil {
+			return nil, err
+		}
+		return ks.NodeIdentity(passphrase)
+	}
+
+	var legacy libp2pcrypto.PrivKey
+	if legacyFile != "" {
+		if _, err := os.Stat(legacyFile); err == nil {
+			legacy, err = ReadLegacyKey(legacyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read plaintext key %s: %s", legacyFile, err)
+			}
+		}
+	}
+
+	passphrase, err := Passphrase(passphraseFile, "New passphrase of the node identity: ", true)
+	if err != nil {
+		return nil, err
+	}
+
+	if legacy == nil {
+		prv, err := ks.NewNodeIdentity(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Println("🔑 Node identity generated")
+		return prv, nil
+	}
+
+	if err = ks.ImportNodeIdentity(legacy, passphrase); err != nil {
+		return nil, err
+	}
+	if err = os.Remove(legacyFile); err != nil {
+		return nil, err
+	}
+	fmt.Println("🔐 Node identity encrypted into the keystore, removed plaintext key", legacyFile)
+	return legacy, nil
+}
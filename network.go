@@ -0,0 +1,177 @@
+package spore
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p/p2p/discovery"
+	"github.com/multiformats/go-multiaddr"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DiscoveryServiceTag is used in our mDNS advertisements to discover other chat peers.
+const DiscoveryServiceTag = "sporep2p"
+
+// ClusterSecret parses the hex-encoded secret string, checks that it is exactly
+// 32 bytes long and returns its value as a byte-slice.
+func ClusterSecret(clusterKey string) ([]byte, error) {
+	secret, err := hex.DecodeString(clusterKey)
+	if err != nil {
+		return nil, err
+	}
+	switch secretLen := len(secret); secretLen {
+	case 0:
+		log.Warning("Cluster secret is empty, cluster will start on unprotected network.")
+		return nil, nil
+	case 32:
+		return secret, nil
+	default:
+		return nil, fmt.Errorf("input secret is %d bytes, cluster secret should be 32", secretLen)
+	}
+}
+
+// CollectBootstrapAddrInfos converts the bootstrap addresses of the
+// configuration to a slice of []peer.AddrInfo
+func CollectBootstrapAddrInfos(bootstrappers []string) ([]peer.AddrInfo, error) {
+	if len(bootstrappers) == 0 {
+		logInfo("🔔 No bootstrappers defined for this node.")
+	}
+
+	addrInfoSlice := make([]peer.AddrInfo, len(bootstrappers))
+	for i, s := range bootstrappers {
+		targetAddr, err := multiaddr.NewMultiaddr(s)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+
+		targetInfo, err := peer.AddrInfoFromP2pAddr(targetAddr)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+		addrInfoSlice[i] = *targetInfo
+		logInfo("🔔 Calling bootstrap node:", s)
+	}
+
+	return addrInfoSlice, nil
+}
+
+func makeDht(ctx context.Context, h host.Host, bootstrappers []string) (*dht.IpfsDHT, error) {
+	bootstrapPeers, err := CollectBootstrapAddrInfos(bootstrappers)
+	if err != nil {
+		return nil, err
+	}
+	idht, err := dht.New(ctx, h,
+		dht.Mode(dht.ModeServer),
+		dht.ProtocolPrefix("/sporep2p/kad/1.0.0"),
+		dht.BootstrapPeers(bootstrapPeers...),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Bootstrapping the DHT")
+	if err = idht.Bootstrap(ctx); err != nil {
+		idht.Close()
+		return nil, err
+	}
+	return idht, nil
+}
+
+// bootstrapNotifiee reports the connections to the bootstrap nodes, and
+// reconnects to those that disconnect until ctx is done
+func bootstrapNotifiee(ctx context.Context, h host.Host, bootstrappers []string) network.Notifiee {
+	return &network.NotifyBundle{
+		ConnectedF: func(n network.Network, c network.Conn) {
+			s := fmt.Sprintf("%s/p2p/%s", c.RemoteMultiaddr(), c.RemotePeer())
+			if find(bootstrappers, s) {
+				fmt.Println("🌟 Connected to Bootstrap Node:", s)
+			}
+		},
+
+		DisconnectedF: func(n network.Network, c network.Conn) {
+			s := fmt.Sprintf("%s/p2p/%s", c.RemoteMultiaddr(), c.RemotePeer())
+			if !find(bootstrappers, s) || ctx.Err() != nil {
+				return
+			}
+			fmt.Println("🛑 Disconnected from Bootstrap Node:", s)
+
+			// thread
+			go func(s string, peerId peer.ID) {
+				for i := 0; i < 100; i++ {
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(2 * time.Second):
+					}
+					targetAddr, _ := multiaddr.NewMultiaddr(s)
+					targetInfo, _ := peer.AddrInfoFromP2pAddr(targetAddr)
+					log.Debugf("Connectedness to bootstrap node %s: %s", s, h.Network().Connectedness(peerId))
+
+					err := h.Connect(ctx, *targetInfo)
+					if err != nil {
+						log.Warn("Trying to connect to bootstrap Peer", s, err)
+					}
+					if h.Network().Connectedness(peerId) == network.Connected {
+						return
+					}
+				}
+			}(s, c.RemotePeer())
+		},
+	}
+}
+
+// discoveryNotifee gets notified when we find a new peer via mDNS discovery
+type discoveryNotifee struct {
+	ctx context.Context
+	h   host.Host
+}
+
+// HandlePeerFound connects to peers discovered via mDNS. Once they're connected,
+// the PubSub system will automatically start interacting with them if they also
+// support PubSub.
+func (n *discoveryNotifee) HandlePeerFound(pi peer.AddrInfo) {
+	log.Infof("discovered new peer %s", pi.ID.Pretty())
+	err := n.h.Connect(n.ctx, pi)
+	if err != nil {
+		log.Errorf("error connecting to peer %s: %s", pi.ID.Pretty(), err)
+	}
+}
+
+// setupMdnsDiscovery creates an mDNS discovery service and attaches it to the
+// libp2p Host. This lets us automatically discover peers on the same LAN and
+// connect to them. The mDNS records are re-published every interval.
+func setupMdnsDiscovery(ctx context.Context, h host.Host, interval time.Duration) (discovery.Service, error) {
+	// setup mDNS discovery to find local peers
+	disc, err := discovery.NewMdnsService(ctx, h, interval, DiscoveryServiceTag)
+	if err != nil {
+		return nil, err
+	}
+
+	disc.RegisterNotifee(&discoveryNotifee{ctx: ctx, h: h})
+	return disc, nil
+}
+
+// logInfo logs to console and logger
+func logInfo(m string, args ...interface{}) {
+	fmt.Println(m, args)
+	log.Info(m, args)
+}
+
+// find reports whether val is an element of slice
+func find(slice []string, val string) bool {
+	for _, item := range slice {
+		if item == val {
+			return true
+		}
+	}
+	return false
+}
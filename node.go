@@ -0,0 +1,373 @@
+// Package spore runs a Spore node: the libp2p host, the chain it replicates
+// and the RPC interface to it. Several nodes can run in one process, each with
+// its own data directory.
+package spore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	connmgr "github.com/libp2p/go-libp2p-connmgr"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	cr "github.com/libp2p/go-libp2p-core/routing"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	secio "github.com/libp2p/go-libp2p-secio"
+	libp2ptls "github.com/libp2p/go-libp2p-tls"
+	"github.com/libp2p/go-libp2p/p2p/discovery"
+	"github.com/sporeframework/spore/config"
+	"github.com/sporeframework/spore/contract"
+	"github.com/sporeframework/spore/dag"
+	"github.com/sporeframework/spore/datadir"
+	"github.com/sporeframework/spore/db"
+	"github.com/sporeframework/spore/protocol"
+	"google.golang.org/grpc"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ShutdownTimeout is how long Stop waits for RPC calls in progress to complete
+var ShutdownTimeout = 10 * time.Second
+
+// Node is a Spore node. It is built from a configuration with New, joins the
+// network with Start and leaves it with Stop.
+type Node struct {
+	conf     *config.Config
+	home     string
+	identity crypto.PrivKey
+
+	lock      *datadir.Lock
+	db        db.DB
+	chain     *protocol.Chain
+	host      host.Host
+	dht       *dht.IpfsDHT
+	mdns      discovery.Service
+	notifiee  network.Notifiee
+	rpc       *grpc.Server
+	rpcAddr   net.Addr
+	cancel    context.CancelFunc
+	rpcDone   chan struct{}
+	isStarted bool
+}
+
+// Option configures a node
+type Option func(*Node)
+
+// WithHome sets the data directory of the node, which holds its database and
+// relative paths of the configuration. It defaults to datadir.Resolve("").
+func WithHome(home string) Option {
+	return func(n *Node) {
+		n.home = home
+	}
+}
+
+// WithIdentity sets the libp2p identity of the node. A random identity is
+// used if not set.
+func WithIdentity(prv crypto.PrivKey) Option {
+	return func(n *Node) {
+		n.identity = prv
+	}
+}
+
+// New returns a node running with the configuration conf, which must not be
+// changed afterwards
+func New(conf *config.Config, opts ...Option) (*Node, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	n := &Node{conf: conf}
+	for _, opt := range opts {
+		opt(n)
+	}
+	if n.home == "" {
+		n.home = datadir.Resolve("")
+	}
+	return n, nil
+}
+
+// Start locks the data directory, loads the chain from the database, then
+// joins the network and serves RPC until Stop is called. The node is stopped
+// again if any of these fails.
+func (n *Node) Start(ctx context.Context) error {
+	if n.isStarted {
+		return errors.New("node already started")
+	}
+	n.isStarted = true
+	ctx, n.cancel = context.WithCancel(ctx)
+
+	err := n.start(ctx)
+	if err != nil {
+		n.Shutdown(ctx)
+	}
+	return err
+}
+
+func (n *Node) start(ctx context.Context) error {
+	var err error
+	n.lock, err = datadir.Acquire(n.home)
+	if err != nil {
+		return err
+	}
+
+	// startup the db
+	// BadgerDB database
+	n.db, err = db.NewBadgerDB(datadir.Path(n.home, n.conf.Storage.Path))
+	if err != nil {
+		return err
+	}
+	n.chain, err = protocol.NewChain(n.db, n.conf)
+	if err != nil {
+		return err
+	}
+
+	err = n.startHost(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Println("🌟 Id:", n.host.ID().Pretty())
+	// print the node's listening addresses
+	fmt.Println("🔖 Listen addresses:", n.host.Addrs())
+
+	ps, err := pubsub.NewGossipSub(ctx, n.host, pubsub.WithMessageIdFn(protocol.MessageID))
+	if err != nil {
+		return err
+	}
+	err = n.chain.Start(ctx, n.host, ps)
+	if err != nil {
+		return err
+	}
+
+	// setup local mDNS discovery
+	if interval := n.conf.Network.MdnsInterval.Duration; interval > 0 {
+		n.mdns, err = setupMdnsDiscovery(ctx, n.host, interval)
+		if err != nil {
+			return err
+		}
+	}
+
+	return n.startRPC()
+}
+
+// startHost creates the libp2p host of the node on its private network, with
+// the DHT for peer routing
+func (n *Node) startHost(ctx context.Context) error {
+	netConf := n.conf.Network
+	psk, err := ClusterSecret(netConf.ClusterKey)
+	if err != nil {
+		return err
+	}
+
+	// DHT Peer routing
+	routing := libp2p.Routing(func(h host.Host) (cr.PeerRouting, error) {
+		var err error
+		n.dht, err = makeDht(ctx, h, netConf.Bootstrappers)
+		return n.dht, err
+	})
+
+	cm := connmgr.NewConnManager(
+		netConf.ConnMgr.LowWater,
+		netConf.ConnMgr.HighWater,
+		netConf.ConnMgr.GracePeriod.Duration,
+	)
+
+	opts := []libp2p.Option{
+		// use a private network
+		libp2p.PrivateNetwork(psk),
+		// listen addresses
+		libp2p.ListenAddrStrings(
+			fmt.Sprintf("/ip4/%s/tcp/%d", netConf.ListenHost, netConf.Port),
+		),
+		// support TLS connections
+		libp2p.Security(libp2ptls.ID, libp2ptls.New),
+		// support secio connections
+		libp2p.Security(secio.ID, secio.New),
+		// support any other default transports (TCP)
+		libp2p.DefaultTransports,
+		// Let this host use the DHT to find other hosts
+		routing,
+		// Connection Manager
+		libp2p.ConnectionManager(cm),
+		// Attempt to open ports using uPNP for NATed hosts.
+		libp2p.NATPortMap(),
+		// Let this host use relays and advertise itself on relays if
+		// it finds it is behind NAT. Use libp2p.Relay(options...) to
+		// enable active relays and more.
+		libp2p.EnableAutoRelay(),
+	}
+	if n.identity != nil {
+		// Use the defined identity
+		opts = append(opts, libp2p.Identity(n.identity))
+	}
+
+	n.host, err = libp2p.New(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	n.notifiee = bootstrapNotifiee(ctx, n.host, netConf.Bootstrappers)
+	n.host.Network().Notify(n.notifiee)
+	return nil
+}
+
+// startRPC serves the RPC interface of the chain in the background
+func (n *Node) startRPC() error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", n.conf.RPC.Port))
+	if err != nil {
+		return err
+	}
+	n.rpcAddr = lis.Addr()
+	fmt.Println("RPC interface listening on tcp", n.rpcAddr)
+
+	n.rpc = grpc.NewServer()
+	protocol.RegisterSporeServer(n.rpc, protocol.NewServer(n.chain))
+
+	n.rpcDone = make(chan struct{})
+	go func(s *grpc.Server, done chan struct{}) {
+		defer close(done)
+		if err := s.Serve(lis); err != nil {
+			log.Errorf("❌ RPC server failed: %s", err)
+		}
+	}(n.rpc, n.rpcDone)
+	return nil
+}
+
+// Stop shuts the node down, giving RPC calls in progress ShutdownTimeout to
+// complete
+func (n *Node) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	return n.Shutdown(ctx)
+}
+
+// Shutdown stops the node in order: it stops accepting RPC calls and waits for
+// those in progress until ctx is done, cancelling them afterwards. It then
+// drains the request handler and the mempool of the chain and persists its
+// state, leaves the network, closes the database and unlocks the data
+// directory. It returns the first error met, after releasing every resource of
+// the node, or the error of ctx if RPC calls had to be cancelled.
+func (n *Node) Shutdown(ctx context.Context) error {
+	if !n.isStarted {
+		return nil
+	}
+	n.isStarted = false
+
+	var errs []error
+	if n.rpc != nil {
+		errs = append(errs, n.stopRPC(ctx))
+		n.rpc = nil
+	}
+	if n.chain != nil {
+		errs = append(errs, n.chain.Stop())
+	}
+	n.cancel()
+	if n.mdns != nil {
+		errs = append(errs, n.mdns.Close())
+		n.mdns = nil
+	}
+	if n.host != nil {
+		n.host.Network().StopNotify(n.notifiee)
+	}
+	if n.dht != nil {
+		errs = append(errs, n.dht.Close())
+		n.dht = nil
+	}
+	if n.host != nil {
+		errs = append(errs, n.host.Close())
+		n.host = nil
+	}
+	if n.db != nil {
+		errs = append(errs, n.db.Close())
+		n.db = nil
+	}
+	if n.lock != nil {
+		errs = append(errs, n.lock.Release())
+		n.lock = nil
+	}
+	n.chain = nil
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopRPC stops the RPC server gracefully, or forcibly once ctx is done
+func (n *Node) stopRPC(ctx context.Context) error {
+	// event subscriptions would otherwise keep the server running
+	if n.chain != nil {
+		n.chain.CloseEvents()
+	}
+
+	stopped := make(chan struct{})
+	go func(s *grpc.Server) {
+		s.GracefulStop()
+		close(stopped)
+	}(n.rpc)
+
+	var err error
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Warn("RPC calls still in progress at shutdown, cancelling them")
+		n.rpc.Stop()
+		<-stopped
+		err = ctx.Err()
+	}
+	<-n.rpcDone
+	return err
+}
+
+// Config returns the configuration of the node
+func (n *Node) Config() *config.Config {
+	return n.conf
+}
+
+// Home returns the data directory of the node
+func (n *Node) Home() string {
+	return n.home
+}
+
+// Host returns the libp2p host of a started node
+func (n *Node) Host() host.Host {
+	return n.host
+}
+
+// Chain returns the chain of a started node
+func (n *Node) Chain() *protocol.Chain {
+	return n.chain
+}
+
+// DAG returns the DAG of a started node. Reads must go through Chain().View
+// while the node is started.
+func (n *Node) DAG() *dag.GreedyGraphMem {
+	if n.chain == nil {
+		return nil
+	}
+	return n.chain.DAG()
+}
+
+// DB returns the database of a started node
+func (n *Node) DB() db.DB {
+	return n.db
+}
+
+// Engine returns the contract engine of a started node
+func (n *Node) Engine() *contract.ContractEngine {
+	if n.chain == nil {
+		return nil
+	}
+	return n.chain.Engine()
+}
+
+// RPCAddr returns the address the RPC interface of a started node listens on
+func (n *Node) RPCAddr() net.Addr {
+	return n.rpcAddr
+}
@@ -0,0 +1,129 @@
+package spore
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/sporeframework/spore/config"
+	"github.com/sporeframework/spore/datadir"
+	"github.com/sporeframework/spore/protocol"
+	"google.golang.org/grpc"
+)
+
+// testConfig returns a configuration of a node on random local ports
+func testConfig() *config.Config {
+	conf := config.Default()
+	conf.Network.ListenHost = "127.0.0.1"
+	conf.Network.Port = 0
+	conf.Network.Bootstrappers = nil
+	conf.Network.MdnsInterval = config.Duration{}
+	conf.RPC.Port = 0
+	return conf
+}
+
+func startNode(t *testing.T, home string) *Node {
+	n, err := New(testConfig(), WithHome(home))
+	if err != nil {
+		t.Fatalf("failed to create node: %s", err)
+	}
+	if err = n.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start node: %s", err)
+	}
+	return n
+}
+
+func TestNode_Several(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spore")
+	if err != nil {
+		t.Fatalf("failed to create directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	a := startNode(t, filepath.Join(dir, "a"))
+	defer a.Stop()
+	b := startNode(t, filepath.Join(dir, "b"))
+	defer b.Stop()
+
+	if a.RPCAddr().String() == b.RPCAddr().String() {
+		t.Errorf("expected nodes to serve RPC on different addresses, got %s", a.RPCAddr())
+	}
+	if a.DB() == b.DB() || a.DAG() == b.DAG() || a.Engine() == b.Engine() {
+		t.Error("expected nodes to have their own state")
+	}
+
+	err = b.Host().Connect(context.Background(), peer.AddrInfo{ID: a.Host().ID(), Addrs: a.Host().Addrs()})
+	if err != nil {
+		t.Fatalf("failed to connect nodes: %s", err)
+	}
+
+	// a data directory is used by a single node at a time
+	other, err := New(testConfig(), WithHome(a.Home()))
+	if err != nil {
+		t.Fatalf("failed to create node: %s", err)
+	}
+	if err = other.Start(context.Background()); !errors.Is(err, datadir.ErrLocked) {
+		t.Fatalf("expected data directory to be locked, got %v", err)
+	}
+
+	if err = a.Stop(); err != nil {
+		t.Fatalf("failed to stop node: %s", err)
+	}
+	if a.DB() != nil {
+		t.Error("expected database of a stopped node to be closed")
+	}
+	if err = a.Start(context.Background()); err != nil {
+		t.Fatalf("failed to restart node: %s", err)
+	}
+}
+
+func TestNode_Shutdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spore")
+	if err != nil {
+		t.Fatalf("failed to create directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	n := startNode(t, dir)
+
+	// an open event stream must not hold the shutdown back
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, n.RPCAddr().String(), grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatalf("failed to dial node: %s", err)
+	}
+	defer conn.Close()
+	stream, err := protocol.NewSporeClient(conn).SubscribeEvents(ctx, &protocol.EventFilter{})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %s", err)
+	}
+	if _, err = stream.Header(); err != nil {
+		t.Fatalf("failed to subscribe: %s", err)
+	}
+
+	if err = n.Shutdown(ctx); err != nil {
+		t.Fatalf("expected graceful shutdown, got %s", err)
+	}
+	if _, err = stream.Recv(); err == nil {
+		t.Error("expected event stream to end")
+	}
+	if n.Host() != nil || n.DB() != nil {
+		t.Error("expected host and database to be closed")
+	}
+	if err = n.Shutdown(ctx); err != nil {
+		t.Errorf("expected shutdown of a stopped node to do nothing, got %s", err)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	conf := testConfig()
+	conf.DAG.K = 0
+	if _, err := New(conf); err == nil {
+		t.Error("expected invalid configuration to be rejected")
+	}
+}
@@ -0,0 +1,349 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const AccountNamespace = "sporeaccount"
+
+// AddressLength is the length of an account address. Recipients of this
+// length receive plain value transfers; longer recipients are contracts.
+const AddressLength = 20
+
+// TransferGas is the gas used by a plain value transfer
+const TransferGas = 100
+
+var (
+	noncePrefix   = []byte("nonce/")
+	balancePrefix = []byte("balance/")
+	genesisKey    = []byte("genesis")
+
+	errInsufficientBalance = errors.New("insufficient balance")
+)
+
+// accountKey returns the database key of an account under the given prefix
+func accountKey(prefix []byte, address []byte) []byte {
+	key := make([]byte, 0, len(prefix)+len(address))
+	key = append(key, prefix...)
+	return append(key, address...)
+}
+
+// getAccountNonce returns the nonce of the next transaction of the account to
+// be executed
+func (c *Chain) getAccountNonce(address []byte) (int32, error) {
+	key := accountKey(noncePrefix, address)
+	ok, err := c.db.Has([]byte(AccountNamespace), key)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	nonceBytes, err := c.db.Get([]byte(AccountNamespace), key)
+	if err != nil {
+		return 0, err
+	}
+	if len(nonceBytes) != 4 {
+		return 0, fmt.Errorf("invalid nonce of %d bytes for account %x", len(nonceBytes), address)
+	}
+	return int32(binary.BigEndian.Uint32(nonceBytes)), nil
+}
+
+func (c *Chain) setAccountNonce(address []byte, nonce int32) error {
+	nonceBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(nonceBytes, uint32(nonce))
+	return c.db.Set([]byte(AccountNamespace), accountKey(noncePrefix, address), nonceBytes)
+}
+
+// reservationTTL is how long the nonce of a transaction accepted over RPC stays
+// reserved while the transaction is on its way to the mempool
+const reservationTTL = time.Minute
+
+// nonceIndex holds the ids of transactions by account and nonce
+type nonceIndex map[string]map[int32]map[string]struct{}
+
+func (x nonceIndex) add(txn *Transaction) {
+	nonces, ok := x[string(txn.From)]
+	if !ok {
+		nonces = make(map[int32]map[string]struct{})
+		x[string(txn.From)] = nonces
+	}
+	ids, ok := nonces[txn.Nonce]
+	if !ok {
+		ids = make(map[string]struct{})
+		nonces[txn.Nonce] = ids
+	}
+	ids[string(txn.Id)] = struct{}{}
+}
+
+func (x nonceIndex) remove(txn *Transaction) {
+	nonces := x[string(txn.From)]
+	ids := nonces[txn.Nonce]
+	delete(ids, string(txn.Id))
+	if len(ids) == 0 {
+		delete(nonces, txn.Nonce)
+	}
+	if len(nonces) == 0 {
+		delete(x, string(txn.From))
+	}
+}
+
+// has reports whether a transaction of the account uses the nonce
+func (x nonceIndex) has(address []byte, nonce int32) bool {
+	return len(x[string(address)][nonce]) > 0
+}
+
+// reservation holds the nonce of a transaction accepted over RPC until it
+// reaches the mempool
+type reservation struct {
+	txn     *Transaction
+	expires time.Time
+}
+
+// getPendingNonce returns the nonce the next transaction submitted for the
+// account must use: the nonce of its next transaction to be executed, past
+// the consecutive nonces of its transactions known to this node, whether they
+// are reserved at ingress, pending in the mempool, or waiting for execution
+// in the DAG. The caller must hold mu.
+func (c *Chain) getPendingNonce(address []byte) (int32, error) {
+	nonce, err := c.getAccountNonce(address)
+	if err != nil {
+		return 0, err
+	}
+
+	c.expireReservations()
+	c.expireOrphans()
+	for c.reservedNonces.has(address, nonce) || c.unexecuted.has(address, nonce) || c.pool.HasNonce(address, nonce) {
+		nonce++
+	}
+	return nonce, nil
+}
+
+// checkNonce accepts a transaction at ingress only if it uses the next nonce
+// of its account, and reserves that nonce. The reservation must be released
+// with releaseNonce if the transaction is not published. A transaction known
+// to the node already is reported as errDuplicateTransaction instead.
+func (c *Chain) checkNonce(txn *Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.knows(txn.Id) {
+		return errDuplicateTransaction
+	}
+
+	nonce, err := c.getPendingNonce(txn.From)
+	if err != nil {
+		return err
+	}
+	if txn.Nonce != nonce {
+		return fmt.Errorf("invalid nonce %d for account %x, expected %d", txn.Nonce, txn.From, nonce)
+	}
+
+	c.reserved[string(txn.Id)] = &reservation{txn: txn, expires: time.Now().Add(reservationTTL)}
+	c.reservedNonces.add(txn)
+	return nil
+}
+
+// knows reports whether a transaction reached this node from the network: it
+// is pending in the mempool, waiting for its parents or in the DAG. The caller
+// must hold mu.
+func (c *Chain) knows(id []byte) bool {
+	exists, _ := c.graph.NodeExists(string(id))
+	return exists || c.orphans.has(string(id)) || c.pool.Get(id) != nil
+}
+
+// releaseNonce releases the nonce reserved for a transaction
+func (c *Chain) releaseNonce(txn *Transaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unreserve(txn.Id)
+}
+
+// unreserve removes the reservation of a transaction, if any. The caller must
+// hold mu.
+func (c *Chain) unreserve(id []byte) {
+	r, ok := c.reserved[string(id)]
+	if !ok {
+		return
+	}
+	delete(c.reserved, string(id))
+	c.reservedNonces.remove(r.txn)
+}
+
+// expireReservations removes the reservations of transactions that never
+// reached the mempool. The caller must hold mu.
+func (c *Chain) expireReservations() {
+	now := time.Now()
+	for id, r := range c.reserved {
+		if now.After(r.expires) {
+			c.unreserve([]byte(id))
+		}
+	}
+}
+
+// useNonce consumes the nonce of a transaction during ordered execution, once
+// its sender is known to be able to pay for the value and all of the gas up
+// front. A replayed or underfunded transaction leaves the nonce of the account
+// to another transaction. The transaction must not be executed if an error is
+// returned. The caller must hold mu.
+func (c *Chain) useNonce(txn *Transaction) error {
+	nonce, err := c.getAccountNonce(txn.From)
+	if err != nil {
+		return err
+	}
+	if txn.Nonce != nonce {
+		return fmt.Errorf("invalid nonce %d for account %x, expected %d", txn.Nonce, txn.From, nonce)
+	}
+
+	err = c.checkBalance(txn)
+	if err != nil {
+		return err
+	}
+	return c.setAccountNonce(txn.From, nonce+1)
+}
+
+// getBalance returns the balance of the account
+func (c *Chain) getBalance(address []byte) (int64, error) {
+	key := accountKey(balancePrefix, address)
+	ok, err := c.db.Has([]byte(AccountNamespace), key)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	balanceBytes, err := c.db.Get([]byte(AccountNamespace), key)
+	if err != nil {
+		return 0, err
+	}
+	if len(balanceBytes) != 8 {
+		return 0, fmt.Errorf("invalid balance of %d bytes for account %x", len(balanceBytes), address)
+	}
+	return int64(binary.BigEndian.Uint64(balanceBytes)), nil
+}
+
+func (c *Chain) setBalance(address []byte, balance int64) error {
+	balanceBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(balanceBytes, uint64(balance))
+	return c.db.Set([]byte(AccountNamespace), accountKey(balancePrefix, address), balanceBytes)
+}
+
+// addBalance adds amount, which may be negative, to the balance of the account
+func (c *Chain) addBalance(address []byte, amount int64) error {
+	balance, err := c.getBalance(address)
+	if err != nil {
+		return err
+	}
+
+	if amount < 0 && balance < -amount {
+		return errInsufficientBalance
+	}
+	if amount > 0 && balance > math.MaxInt64-amount {
+		return fmt.Errorf("balance of account %x overflows", address)
+	}
+	return c.setBalance(address, balance+amount)
+}
+
+// transfer moves value from one account to another. Both new balances are
+// checked before either is written, so that a failed credit does not destroy
+// the value.
+func (c *Chain) transfer(from, to []byte, value int64) error {
+	if value == 0 {
+		return nil
+	}
+
+	fromBalance, err := c.getBalance(from)
+	if err != nil {
+		return err
+	}
+	if fromBalance < value {
+		return errInsufficientBalance
+	}
+	if bytes.Equal(from, to) {
+		return nil
+	}
+	toBalance, err := c.getBalance(to)
+	if err != nil {
+		return err
+	}
+	if toBalance > math.MaxInt64-value {
+		return fmt.Errorf("balance of account %x overflows", to)
+	}
+
+	err = c.setBalance(from, fromBalance-value)
+	if err != nil {
+		return err
+	}
+	err = c.setBalance(to, toBalance+value)
+	if err != nil {
+		// restore the sender so the value is not lost
+		if restoreErr := c.setBalance(from, fromBalance); restoreErr != nil {
+			log.Errorf("❌ failed to restore balance of account %x: %s", from, restoreErr)
+		}
+	}
+	return err
+}
+
+// maxFee returns the fee a transaction pays if it uses all of its gas
+func maxFee(txn *Transaction) (int64, error) {
+	if txn.Gas < 0 || txn.GasPrice < 0 || txn.Value < 0 {
+		return 0, errors.New("gas, gas price and value must not be negative")
+	}
+	if txn.GasPrice > 0 && txn.Gas > math.MaxInt64/txn.GasPrice {
+		return 0, errors.New("transaction fee overflows")
+	}
+	return txn.Gas * txn.GasPrice, nil
+}
+
+// checkBalance verifies that the sender can pay for the value and the maximum
+// fee of a transaction
+func (c *Chain) checkBalance(txn *Transaction) error {
+	fee, err := maxFee(txn)
+	if err != nil {
+		return err
+	}
+	if txn.Value > math.MaxInt64-fee {
+		return errors.New("transaction cost overflows")
+	}
+
+	balance, err := c.getBalance(txn.From)
+	if err != nil {
+		return err
+	}
+	if balance < txn.Value+fee {
+		return fmt.Errorf("%s: account %x has %d, transaction costs up to %d", errInsufficientBalance, txn.From, balance, txn.Value+fee)
+	}
+	return nil
+}
+
+// applyGenesisAllocation credits the genesis balances of the configuration to
+// a new ledger. They are credited once, when the node starts with an empty
+// ledger, and must be the same on every node of the network.
+func (c *Chain) applyGenesisAllocation() error {
+	ok, err := c.db.Has([]byte(AccountNamespace), genesisKey)
+	if err != nil || ok {
+		return err
+	}
+
+	for addressHex, balance := range c.conf.Genesis {
+		address, err := hex.DecodeString(strings.TrimPrefix(addressHex, "0x"))
+		if err != nil {
+			return fmt.Errorf("invalid genesis address %s: %s", addressHex, err)
+		}
+		if len(address) != AddressLength || balance < 0 {
+			return fmt.Errorf("invalid genesis allocation of %d to %s", balance, addressHex)
+		}
+
+		err = c.setBalance(address, balance)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.db.Set([]byte(AccountNamespace), genesisKey, []byte{1})
+}
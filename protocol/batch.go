@@ -0,0 +1,131 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// MaxBatchMessageSize is the size above which the accepted transactions of a
+// batch are split into several pubsub messages, well below the 1MiB message
+// limit of pubsub
+const MaxBatchMessageSize = 512 << 10
+
+// SendBatch implements Spore.SendBatch. The most transactions of a batch,
+// and of a SendStream call that are checked together, is rpc.maxBatchSize.
+func (s *server) SendBatch(ctx context.Context, in *TransactionBatch) (*BatchResponse, error) {
+	if maxBatchSize := s.chain.conf.RPC.MaxBatchSize; len(in.Transactions) > maxBatchSize {
+		return nil, fmt.Errorf("batch of %d transactions exceeds the maximum of %d", len(in.Transactions), maxBatchSize)
+	}
+	return &BatchResponse{Results: s.chain.sendBatch(in.Transactions)}, nil
+}
+
+// SendStream implements Spore.SendStream
+func (s *server) SendStream(stream Spore_SendStreamServer) error {
+	resp := &BatchResponse{}
+	maxBatchSize := s.chain.conf.RPC.MaxBatchSize
+	txns := make([]*Transaction, 0, maxBatchSize)
+	for {
+		txn, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		txns = append(txns, txn)
+		if len(txns) == maxBatchSize {
+			resp.Results = append(resp.Results, s.chain.sendBatch(txns)...)
+			txns = txns[:0]
+		}
+	}
+
+	resp.Results = append(resp.Results, s.chain.sendBatch(txns)...)
+	return stream.SendAndClose(resp)
+}
+
+// sendBatch checks transactions received over RPC and publishes the accepted
+// ones, returning a result per transaction in order. The stateless checks,
+// signatures above all, run in parallel. The stateful ones run in order, so
+// that consecutive nonces of an account are accepted within a batch.
+func (c *Chain) sendBatch(txns []*Transaction) []*BatchResult {
+	results := make([]*BatchResult, len(txns))
+	errs := make([]error, len(txns))
+
+	var wg sync.WaitGroup
+	next := make(chan int)
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = c.checkTransaction(txns[i], len(txns[i].GetTo()) == 0)
+			}
+		}()
+	}
+	for i := range txns {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var accepted []int
+	for i, txn := range txns {
+		if errs[i] == nil {
+			errs[i] = c.acceptTransaction(txn)
+		}
+		if errs[i] == errDuplicateTransaction {
+			results[i] = &BatchResult{TransactionId: txn.Id}
+			continue
+		}
+		if errs[i] != nil {
+			results[i] = &BatchResult{Error: errs[i].Error()}
+			continue
+		}
+		results[i] = &BatchResult{TransactionId: txn.Id}
+		accepted = append(accepted, i)
+	}
+
+	// accepted transactions are published a few pubsub messages at a time,
+	// rather than one message each. Once a message fails, the transactions
+	// not published yet are failed too, so that no later nonce of an account
+	// is published without the earlier ones.
+	var chunk []int
+	var failed error
+	size := 0
+	flush := func() {
+		if len(chunk) == 0 {
+			return
+		}
+		if failed == nil {
+			req := &Request{Type: Request_BATCH, Transactions: make([]*Transaction, len(chunk))}
+			for j, i := range chunk {
+				req.Transactions[j] = txns[i]
+			}
+			failed = c.publishRequest(req)
+		}
+		if failed != nil {
+			for _, i := range chunk {
+				c.releaseNonce(txns[i])
+				results[i] = &BatchResult{Error: failed.Error()}
+			}
+		}
+		chunk, size = chunk[:0], 0
+	}
+	for _, i := range accepted {
+		n := proto.Size(txns[i])
+		if size+n > c.maxBatchMessageSize {
+			flush()
+		}
+		chunk = append(chunk, i)
+		size += n
+	}
+	flush()
+
+	return results
+}
@@ -0,0 +1,35 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSendBatch_PublishFailure(t *testing.T) {
+	c, cleanup := newTestChain(t)
+	defer cleanup()
+	c.conf.RPC.MinGasPrice = 0
+	prv, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(prv.PublicKey).Bytes()
+
+	// one message per transaction; without a network the first one fails
+	c.maxBatchMessageSize = 1
+
+	var txns []*Transaction
+	for nonce := int32(0); nonce < 3; nonce++ {
+		txns = append(txns, signedBy(t, prv, &Transaction{To: make([]byte, AddressLength), Gas: TransferGas, Nonce: nonce}))
+	}
+	for i, result := range c.sendBatch(txns) {
+		if result.Error == "" {
+			t.Errorf("expected transaction %d not to be published", i)
+		}
+	}
+
+	c.mu.Lock()
+	nonce, err := c.getPendingNonce(address)
+	c.mu.Unlock()
+	if err != nil || nonce != 0 {
+		t.Errorf("expected nonces of unpublished transactions to be released, got pending nonce %d %v", nonce, err)
+	}
+}
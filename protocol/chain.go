@@ -0,0 +1,259 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sporeframework/spore/config"
+	"github.com/sporeframework/spore/contract"
+	"github.com/sporeframework/spore/dag"
+	"github.com/sporeframework/spore/db"
+)
+
+// Chain is the replicated state of a node: the DAG of transactions, the
+// database it and the ledger are stored in, the contract engine executing it
+// and the mempool of transactions waiting to enter it. Each chain has its own
+// database, so that several of them can run in one process.
+type Chain struct {
+	conf *config.Config
+
+	db         db.DB
+	graph      *dag.GreedyGraphMem
+	graphStore *dag.GraphStore
+	engine     *contract.ContractEngine
+
+	// pool holds the validated transactions waiting for DAG insertion
+	pool *Mempool
+
+	// maxBatchMessageSize is the size above which batches are split into
+	// several pubsub messages, MaxBatchMessageSize
+	maxBatchMessageSize int
+
+	// mu serializes changes to the DAG, the ledger and execution
+	mu sync.Mutex
+
+	// orphans holds the transactions waiting for a parent missing from the
+	// graph
+	orphans *orphanPool
+
+	// unexecuted indexes the transactions in the DAG, or waiting for their
+	// parents, that are not executed yet
+	unexecuted nonceIndex
+
+	// reserved holds the nonces of transactions accepted over RPC by id, until
+	// they reach the mempool
+	reserved       map[string]*reservation
+	reservedNonces nonceIndex
+
+	// executedIndex is the number of executed transactions, and the execution
+	// sequence number of the next receipt. It is persisted so that execution
+	// resumes after a restart.
+	executedIndex uint64
+
+	// executed holds the ids of the executed transactions, so that each is
+	// executed once wherever it ends up in the DAG order
+	executed map[string]struct{}
+
+	// executedPrefix is the length of the prefix of the DAG order that was
+	// executed entirely at the last walk, where the next one starts
+	executedPrefix int
+
+	// subscribers receive live events; they are removed when they fall too far
+	// behind
+	subscribers   map[*subscriber]struct{}
+	subscribersMu sync.Mutex
+
+	// eventsClosed is closed by CloseEvents to end the event subscriptions
+	eventsClosed    chan struct{}
+	closeEventsOnce sync.Once
+
+	// syncing tracks the peers a sync is currently running with, and streams
+	// the inbound sync streams being served while the chain is started
+	syncing   map[peer.ID]struct{}
+	streams   map[network.Stream]struct{}
+	syncingMu sync.Mutex
+
+	// the network of a started chain
+	host     host.Host
+	ps       *pubsub.PubSub
+	sub      *pubsub.Subscription
+	notifiee network.Notifiee
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewChain loads the chain stored in the database: it rebuilds the DAG,
+// credits the genesis allocation of a new ledger, resumes execution and
+// restores the mempool
+func NewChain(database db.DB, conf *config.Config) (*Chain, error) {
+	c := &Chain{
+		conf:           conf,
+		db:             database,
+		orphans:        newOrphanPool(),
+		unexecuted:     make(nonceIndex),
+		reserved:       make(map[string]*reservation),
+		reservedNonces: make(nonceIndex),
+		executed:       make(map[string]struct{}),
+		subscribers:    make(map[*subscriber]struct{}),
+		eventsClosed:   make(chan struct{}),
+		syncing:        make(map[peer.ID]struct{}),
+
+		maxBatchMessageSize: MaxBatchMessageSize,
+	}
+
+	// rebuild the DAG from the nodes persisted before the last shutdown
+	var err error
+	c.graphStore, err = dag.NewGraphStore(database, []byte(DagNamespace))
+	if err != nil {
+		return nil, err
+	}
+	c.graph, err = c.graphStore.Load(conf.DAG.K)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("Loaded DAG nodes: ", c.graphStore.Count())
+
+	// contracts are rehydrated from the database on their first call
+	c.engine, err = contract.NewPersistentContractEngine(database, []byte(ContractNamespace))
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.applyGenesisAllocation()
+	if err != nil {
+		return nil, err
+	}
+
+	// resume execution where it stopped before the last shutdown
+	err = c.loadExecutedIndex()
+	if err != nil {
+		return nil, err
+	}
+	err = c.loadUnexecuted()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.executeConfirmed()
+	c.mu.Unlock()
+
+	c.pool = NewMempool(conf.Mempool.Size)
+	err = c.loadMempool()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Start connects the chain to the network: it validates and handles the
+// requests of the transaction topic, syncs the DAG with the peers of the host
+// and moves transactions from the mempool into the DAG, until Stop is called
+// or ctx is done.
+func (c *Chain) Start(ctx context.Context, h host.Host, ps *pubsub.PubSub) error {
+	if c.cancel != nil {
+		return errors.New("chain already started")
+	}
+
+	// invalid messages are neither processed nor propagated to other peers
+	err := ps.RegisterTopicValidator(PubsubTopic, c.validateMessage)
+	if err != nil {
+		return err
+	}
+	sub, err := ps.Subscribe(PubsubTopic)
+	if err != nil {
+		ps.UnregisterTopicValidator(PubsubTopic)
+		return err
+	}
+
+	c.host, c.ps, c.sub = h, ps, sub
+	ctx, c.cancel = context.WithCancel(ctx)
+
+	c.wg.Add(2)
+	go func() {
+		defer c.wg.Done()
+		c.handleRequests(ctx, sub)
+	}()
+	go func() {
+		defer c.wg.Done()
+		c.runMempool(ctx)
+	}()
+
+	// fetch missing DAG history from peers, now and whenever they reconnect
+	c.startSync(ctx)
+	return nil
+}
+
+// Stop disconnects the chain from the network, resets the inbound sync streams,
+// waits for the request handler, the mempool, syncs and any change in progress
+// to finish, then persists the mempool. The chain can still be read afterwards, until its database is
+// closed.
+func (c *Chain) Stop() error {
+	if c.cancel == nil {
+		return nil
+	}
+	c.cancel()
+	c.host.Network().StopNotify(c.notifiee)
+	c.host.RemoveStreamHandler(SyncProtocol)
+	c.closeSyncStreams()
+	c.wg.Wait()
+
+	c.sub.Cancel()
+	if err := c.ps.UnregisterTopicValidator(PubsubTopic); err != nil {
+		log.Warnf("failed to unregister topic validator: %s", err)
+	}
+	c.cancel = nil
+
+	// wait for a transaction still being added or executed, by RPC calls
+	// cancelled at shutdown for instance
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.storeMempool(); err != nil {
+		return fmt.Errorf("failed to persist mempool: %s", err)
+	}
+	return nil
+}
+
+// CloseEvents ends the event subscriptions of the chain and rejects new ones,
+// so that an RPC server can stop gracefully without waiting for subscribers to
+// leave
+func (c *Chain) CloseEvents() {
+	c.closeEventsOnce.Do(func() {
+		close(c.eventsClosed)
+	})
+}
+
+// DB returns the database of the chain
+func (c *Chain) DB() db.DB {
+	return c.db
+}
+
+// DAG returns the DAG of the chain. It is not safe for concurrent use, so
+// reads must go through View while the chain is started.
+func (c *Chain) DAG() *dag.GreedyGraphMem {
+	return c.graph
+}
+
+// View calls fn with the DAG, while no transaction is added or executed
+func (c *Chain) View(fn func(g *dag.GreedyGraphMem) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return fn(c.graph)
+}
+
+// Engine returns the contract engine of the chain
+func (c *Chain) Engine() *contract.ContractEngine {
+	return c.engine
+}
+
+// Mempool returns the pending transactions of the chain
+func (c *Chain) Mempool() *Mempool {
+	return c.pool
+}
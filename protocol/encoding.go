@@ -0,0 +1,82 @@
+package protocol
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// Canonical transaction encoding, version 1
+//
+// A transaction is signed and identified by the sha256 hash of its signing
+// bytes. The signing bytes are the ASCII tag "spore/txn/1" followed by these
+// fields of the transaction, in this order:
+//
+//	to        bytes
+//	from      bytes
+//	data      bytes
+//	gas       int64
+//	gasPrice  int64
+//	nonce     int32
+//	value     int64
+//	contract  bool
+//	parents   list of bytes
+//
+// bytes are a 4-byte big-endian length followed by the bytes. Integers are
+// fixed width, big-endian and two's complement. A bool is one byte, 0 or 1. A
+// list is a 4-byte big-endian count followed by its elements.
+//
+// The id, created, signature, scheme and publicKey fields are not part of the
+// encoding. By default the signature is a 65-byte secp256k1 [R || S || V]
+// signature of the hash; signature.go lists the other schemes. The hash is the
+// transaction id used by every RPC, the database and the DAG.
+// Test vectors are in testdata/transaction_vectors.json.
+
+// signingTag versions the canonical encoding
+const signingTag = "spore/txn/1"
+
+// SigningBytes returns the canonical encoding of a transaction
+func SigningBytes(txn *Transaction) []byte {
+	buf := []byte(signingTag)
+	buf = appendBytes(buf, txn.To)
+	buf = appendBytes(buf, txn.From)
+	buf = appendBytes(buf, txn.Data)
+	buf = appendUint64(buf, uint64(txn.Gas))
+	buf = appendUint64(buf, uint64(txn.GasPrice))
+	buf = appendUint32(buf, uint32(txn.Nonce))
+	buf = appendUint64(buf, uint64(txn.Value))
+	if txn.Contract {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+
+	buf = appendUint32(buf, uint32(len(txn.Parents)))
+	for _, p := range txn.Parents {
+		buf = appendBytes(buf, p)
+	}
+	return buf
+}
+
+// TransactionHash returns the hash of the canonical encoding of a transaction,
+// which is both what is signed and the transaction id
+func TransactionHash(txn *Transaction) []byte {
+	hash := sha256.Sum256(SigningBytes(txn))
+	return hash[:]
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func appendBytes(buf []byte, b []byte) []byte {
+	buf = appendUint32(buf, uint32(len(b)))
+	return append(buf, b...)
+}
@@ -0,0 +1,102 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// transactionVectors are the cross-language test vectors of the canonical
+// transaction encoding
+type transactionVectors struct {
+	PrivateKey string `json:"privateKey"`
+	Vectors    []struct {
+		Name        string `json:"name"`
+		Transaction struct {
+			To       string   `json:"to"`
+			From     string   `json:"from"`
+			Data     string   `json:"data"`
+			Gas      int64    `json:"gas"`
+			GasPrice int64    `json:"gasPrice"`
+			Nonce    int32    `json:"nonce"`
+			Value    int64    `json:"value"`
+			Contract bool     `json:"contract"`
+			Parents  []string `json:"parents"`
+		} `json:"transaction"`
+		SigningBytes string `json:"signingBytes"`
+		Hash         string `json:"hash"`
+		Signature    string `json:"signature"`
+	} `json:"vectors"`
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex %q: %s", s, err)
+	}
+	return b
+}
+
+func TestTransactionVectors(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/transaction_vectors.json")
+	if err != nil {
+		t.Fatalf("failed to read test vectors: %s", err)
+	}
+
+	vectors := &transactionVectors{}
+	err = json.Unmarshal(data, vectors)
+	if err != nil {
+		t.Fatalf("failed to parse test vectors: %s", err)
+	}
+
+	prv, err := crypto.HexToECDSA(vectors.PrivateKey)
+	if err != nil {
+		t.Fatalf("invalid private key: %s", err)
+	}
+
+	for _, v := range vectors.Vectors {
+		txn := &Transaction{
+			To:       mustDecodeHex(t, v.Transaction.To),
+			From:     mustDecodeHex(t, v.Transaction.From),
+			Data:     mustDecodeHex(t, v.Transaction.Data),
+			Gas:      v.Transaction.Gas,
+			GasPrice: v.Transaction.GasPrice,
+			Nonce:    v.Transaction.Nonce,
+			Value:    v.Transaction.Value,
+			Contract: v.Transaction.Contract,
+		}
+		for _, p := range v.Transaction.Parents {
+			txn.Parents = append(txn.Parents, mustDecodeHex(t, p))
+		}
+
+		if signingBytes := hex.EncodeToString(SigningBytes(txn)); signingBytes != v.SigningBytes {
+			t.Errorf("%s: incorrect signing bytes %s, expected %s", v.Name, signingBytes, v.SigningBytes)
+		}
+		if hash := hex.EncodeToString(TransactionHash(txn)); hash != v.Hash {
+			t.Errorf("%s: incorrect hash %s, expected %s", v.Name, hash, v.Hash)
+		}
+
+		// metadata is not part of the hash
+		txn.Id = []byte("id")
+		txn.Created = 1
+		if hash := hex.EncodeToString(TransactionHash(txn)); hash != v.Hash {
+			t.Errorf("%s: hash depends on metadata", v.Name)
+		}
+
+		if v.Signature == "" {
+			continue
+		}
+		sig, err := crypto.Sign(TransactionHash(txn), prv)
+		if err != nil || !bytes.Equal(sig, mustDecodeHex(t, v.Signature)) {
+			t.Errorf("%s: incorrect signature %x, expected %s", v.Name, sig, v.Signature)
+		}
+		txn.Signature = sig
+		if err := verifySignature(txn); err != nil {
+			t.Errorf("%s: signature does not verify: %s", v.Name, err)
+		}
+	}
+}
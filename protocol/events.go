@@ -0,0 +1,188 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/metadata"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind by before
+// it is dropped
+const subscriberBuffer = 256
+
+var (
+	errSlowSubscriber = errors.New("subscriber fell behind, resume from the last index received")
+	errEventsClosed   = errors.New("node is shutting down, resume from the last index received")
+)
+
+type subscriber struct {
+	filter *EventFilter
+	events chan *Event
+}
+
+// matches reports whether the event matches every field set in the filter
+func (f *EventFilter) matches(event *Event) bool {
+	if len(f.Contract) > 0 && !bytes.Equal(f.Contract, event.Contract) {
+		return false
+	}
+	if len(f.Topic) > 0 && !bytes.Equal(f.Topic, event.Topic) {
+		return false
+	}
+	if len(f.From) > 0 && !bytes.Equal(f.From, event.From) {
+		return false
+	}
+	return true
+}
+
+// subscribe registers a subscriber for live events. The caller must hold mu,
+// so that no executed transaction is missed between its replay and the live
+// events.
+func (c *Chain) subscribe(filter *EventFilter) *subscriber {
+	sub := &subscriber{filter: filter, events: make(chan *Event, subscriberBuffer)}
+
+	c.subscribersMu.Lock()
+	c.subscribers[sub] = struct{}{}
+	c.subscribersMu.Unlock()
+	return sub
+}
+
+// unsubscribe removes a subscriber if it was not already dropped
+func (c *Chain) unsubscribe(sub *subscriber) {
+	c.subscribersMu.Lock()
+	delete(c.subscribers, sub)
+	c.subscribersMu.Unlock()
+}
+
+// publish sends events to every subscriber they match. A subscriber whose
+// buffer is full is dropped by closing its channel.
+func (c *Chain) publish(events ...*Event) {
+	c.subscribersMu.Lock()
+	defer c.subscribersMu.Unlock()
+
+	for sub := range c.subscribers {
+		for _, event := range events {
+			if !sub.filter.matches(event) {
+				continue
+			}
+
+			select {
+			case sub.events <- event:
+				continue
+			default:
+			}
+			delete(c.subscribers, sub)
+			close(sub.events)
+			break
+		}
+	}
+}
+
+// publishAdded notifies subscribers that a transaction was added to the DAG
+func (c *Chain) publishAdded(txn *Transaction) {
+	c.publish(&Event{
+		Type:          Event_TRANSACTION_ADDED,
+		TransactionId: txn.Id,
+		From:          txn.From,
+		Contract:      txn.To,
+	})
+}
+
+// executedEvents returns the events of an executed transaction: its execution
+// followed by the events emitted by contracts, which are stamped with the order
+// index of the receipt
+func executedEvents(txn *Transaction, receipt *Receipt) []*Event {
+	events := []*Event{{
+		Type:          Event_TRANSACTION_EXECUTED,
+		TransactionId: txn.Id,
+		From:          txn.From,
+		Contract:      txn.To,
+		Index:         receipt.Index,
+	}}
+
+	for _, event := range receipt.Events {
+		event.Index = receipt.Index
+		events = append(events, event)
+	}
+	return events
+}
+
+// getEvents returns the events of the transaction with the execution sequence
+// number, or nil if there is none
+func (c *Chain) getEvents(index uint64) ([]*Event, error) {
+	id, err := c.getExecutedID(index)
+	if err != nil || id == nil {
+		return nil, err
+	}
+
+	receipt, err := c.getReceipt(id)
+	if err != nil {
+		return nil, err
+	}
+	txn, err := c.getTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+	return executedEvents(txn, receipt), nil
+}
+
+// SubscribeEvents implements Spore.SubscribeEvents
+func (s *server) SubscribeEvents(filter *EventFilter, stream Spore_SubscribeEventsServer) error {
+	select {
+	case <-s.chain.eventsClosed:
+		return errEventsClosed
+	default:
+	}
+
+	// register for live events and note where they start, so that the replay
+	// below ends exactly where they begin
+	s.chain.mu.Lock()
+	sub := s.chain.subscribe(filter)
+	replayEnd := s.chain.executedIndex
+	s.chain.mu.Unlock()
+	defer s.chain.unsubscribe(sub)
+
+	// let the client know that live events are registered
+	err := stream.SendHeader(metadata.MD{})
+	if err != nil {
+		return err
+	}
+
+	if filter.Resume {
+		for index := filter.Cursor; index < replayEnd; index++ {
+			events, err := s.chain.getEvents(index)
+			if err != nil {
+				return err
+			}
+
+			for _, event := range events {
+				if !filter.matches(event) {
+					continue
+				}
+				err = stream.Send(event)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-s.chain.eventsClosed:
+			return errEventsClosed
+		case event, ok := <-sub.events:
+			if !ok {
+				log.Debugf("dropped slow event subscriber")
+				return errSlowSubscriber
+			}
+			err := stream.Send(event)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
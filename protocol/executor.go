@@ -0,0 +1,353 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sporeframework/spore/contract"
+	"google.golang.org/protobuf/proto"
+)
+
+const ExecutorNamespace = "sporeexec"
+
+var executedIndexKey = []byte("executedIndex")
+
+// loadExecutedIndex restores the number of executed transactions, and the ids
+// of those transactions from their execution sequence numbers
+func (c *Chain) loadExecutedIndex() error {
+	ok, err := c.db.Has([]byte(ExecutorNamespace), executedIndexKey)
+	if err != nil || !ok {
+		return err
+	}
+
+	indexBytes, err := c.db.Get([]byte(ExecutorNamespace), executedIndexKey)
+	if err != nil {
+		return err
+	}
+	if len(indexBytes) != 8 {
+		return fmt.Errorf("invalid executed index of %d bytes", len(indexBytes))
+	}
+	c.executedIndex = binary.BigEndian.Uint64(indexBytes)
+
+	for index := uint64(0); index < c.executedIndex; index++ {
+		id, err := c.getExecutedID(index)
+		if err != nil {
+			return err
+		}
+		if id == nil {
+			return fmt.Errorf("no transaction with execution sequence number %d", index)
+		}
+		c.executed[string(id)] = struct{}{}
+	}
+	return nil
+}
+
+// loadUnexecuted indexes the transactions of the DAG not executed yet
+func (c *Chain) loadUnexecuted() error {
+	for _, id := range c.graph.Nodes() {
+		if _, ok := c.executed[id]; ok {
+			continue
+		}
+		txn, err := c.getTransaction([]byte(id))
+		if err != nil {
+			return err
+		}
+		c.unexecuted.add(txn)
+	}
+	return nil
+}
+
+func (c *Chain) storeExecutedIndex() error {
+	indexBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(indexBytes, c.executedIndex)
+	return c.db.Set([]byte(ExecutorNamespace), executedIndexKey, indexBytes)
+}
+
+// executeConfirmed walks the DAG order and executes every transaction not
+// executed yet that is at least dag.confirmationDepth below the coloring tip.
+// Until then its position in the PHANTOM order may still change. The walk
+// resumes after the prefix of the order executed entirely, and skips the
+// executed ids past it. History merged later, through sync after a partition
+// for instance, may be ordered inside that prefix, in which case the walk
+// starts from the beginning of the order. A transaction ahead of the nonce of
+// its account is deferred, and executes right after the transaction before
+// it. The caller must hold mu.
+func (c *Chain) executeConfirmed() {
+	tip := c.graph.ColoringTip()
+	if tip == "" {
+		return
+	}
+
+	tipScore, err := c.graph.BlueScore(tip)
+	if err != nil {
+		log.Errorf("❌ failed to get blue score of coloring tip: %s", err)
+		return
+	}
+
+	order, err := c.graph.Order()
+	if err != nil {
+		log.Errorf("❌ failed to order DAG: %s", err)
+		return
+	}
+
+	// the prefix still holds executed transactions only if every transaction
+	// not executed yet is past it
+	start := c.executedPrefix
+	if start > len(order) || countUnexecuted(order[start:], c.executed) != len(order)-len(c.executed) {
+		start = 0
+	}
+	defer func() {
+		for start < len(order) {
+			if _, ok := c.executed[order[start]]; !ok {
+				break
+			}
+			start++
+		}
+		c.executedPrefix = start
+	}()
+
+	// transactions ahead of the nonce of their account wait for the ones
+	// before them, by account and nonce
+	deferred := make(map[string]map[int32]*Transaction)
+
+	for _, id := range order[start:] {
+		if _, ok := c.executed[id]; ok {
+			continue
+		}
+
+		score, err := c.graph.BlueScore(id)
+		if err != nil {
+			log.Errorf("❌ failed to get blue score of node %x: %s", id, err)
+			return
+		}
+		if tipScore-score < c.conf.DAG.ConfirmationDepth {
+			return
+		}
+
+		txn, err := c.getTransaction([]byte(id))
+		if err != nil {
+			log.Errorf("❌ failed to load transaction %x for execution: %s", id, err)
+			return
+		}
+
+		nonce, err := c.getAccountNonce(txn.From)
+		if err != nil {
+			log.Errorf("❌ failed to get nonce of account %x: %s", txn.From, err)
+			return
+		}
+		if txn.Nonce > nonce {
+			log.Debugf("deferring transaction %x until nonce %d of account %x", txn.Id, txn.Nonce, txn.From)
+			if deferred[string(txn.From)] == nil {
+				deferred[string(txn.From)] = make(map[int32]*Transaction)
+			}
+			if _, ok := deferred[string(txn.From)][txn.Nonce]; !ok {
+				deferred[string(txn.From)][txn.Nonce] = txn
+			}
+			continue
+		}
+		c.record(txn, c.execute(txn))
+
+		// then the transactions of the account that were waiting on it, as
+		// long as the one before used its nonce: a replayed or underfunded
+		// transaction leaves it to another
+		for next := txn.Nonce + 1; ; next++ {
+			if nonce, err := c.getAccountNonce(txn.From); err != nil || nonce != next {
+				break
+			}
+			waiting, ok := deferred[string(txn.From)][next]
+			if !ok {
+				break
+			}
+			delete(deferred[string(txn.From)], next)
+			c.record(waiting, c.execute(waiting))
+		}
+	}
+}
+
+// countUnexecuted returns the number of ids of the order not executed
+func countUnexecuted(order []string, executed map[string]struct{}) int {
+	n := 0
+	for _, id := range order {
+		if _, ok := executed[id]; !ok {
+			n++
+		}
+	}
+	return n
+}
+
+// record stores the receipt of an executed transaction under the next
+// execution sequence number, marks the transaction executed and notifies
+// subscribers. The caller must hold mu.
+func (c *Chain) record(txn *Transaction, receipt *Receipt) {
+	receipt.Index = c.executedIndex
+	events := executedEvents(txn, receipt)
+	if err := c.storeReceipt(receipt); err != nil {
+		log.Errorf("❌ failed to store receipt of transaction %x: %s", txn.Id, err)
+	}
+	c.publish(events...)
+
+	c.executed[string(txn.Id)] = struct{}{}
+	c.unexecuted.remove(txn)
+	c.unreserve(txn.Id)
+	c.executedIndex++
+	if err := c.storeExecutedIndex(); err != nil {
+		log.Errorf("❌ failed to store executed index %d: %s", c.executedIndex, err)
+	}
+}
+
+// execute runs a single transaction and returns its receipt. A transaction
+// without a recipient deploys its data as a contract, a transaction to an
+// account address transfers value, and any other transaction calls the
+// recipient contract. The sender pays for the gas used at the transaction's
+// gas price.
+func (c *Chain) execute(txn *Transaction) *Receipt {
+	receipt := &Receipt{TransactionId: txn.Id}
+
+	// replayed transactions are not executed, and neither are those whose
+	// sender cannot pay for the value and all of the gas up front
+	err := c.useNonce(txn)
+	if err != nil {
+		log.Warnf("skipping transaction %x: %s", txn.Id, err)
+		receipt.Error = err.Error()
+		return receipt
+	}
+
+	switch {
+	case len(txn.To) == 0:
+		err = c.executeDeploy(txn, receipt)
+	case len(txn.To) == AddressLength:
+		err = c.executeTransfer(txn, receipt)
+	default:
+		err = c.executeCall(txn, receipt)
+	}
+	switch {
+	case err == contract.ErrOutOfGas:
+		log.Warnf("transaction %x ran out of gas, limit %d", txn.Id, txn.Gas)
+		receipt.Error = err.Error()
+	case err != nil:
+		log.Errorf("❌ transaction %x failed: %s", txn.Id, err)
+		receipt.Error = err.Error()
+	default:
+		receipt.Status = Receipt_SUCCESS
+	}
+
+	// unused gas is not charged; the fee is burned
+	err = c.addBalance(txn.From, -receipt.GasUsed*txn.GasPrice)
+	if err != nil {
+		log.Errorf("❌ failed to charge fee for transaction %x: %s", txn.Id, err)
+	}
+	return receipt
+}
+
+// executeDeploy creates the contract in the data of the transaction. Its id
+// is the result of the receipt.
+func (c *Chain) executeDeploy(txn *Transaction, receipt *Receipt) error {
+	deploymentGas, err := contract.DeploymentGas(txn.Data)
+	if err != nil {
+		receipt.GasUsed = txn.Gas
+		return err
+	}
+	if int64(deploymentGas) > txn.Gas {
+		receipt.GasUsed = txn.Gas
+		return contract.ErrOutOfGas
+	}
+
+	contractID, gas, err := c.engine.CreateWasmContract(txn.Data)
+	receipt.GasUsed = int64(gas)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Contract created, ID: %s, Gas: %d\n", hex.EncodeToString(contractID[:]), gas)
+	receipt.Result = contractID[:]
+
+	return c.transfer(txn.From, contractID[:], txn.Value)
+}
+
+// executeTransfer moves the value of the transaction to the recipient account
+func (c *Chain) executeTransfer(txn *Transaction, receipt *Receipt) error {
+	if txn.Gas < TransferGas {
+		receipt.GasUsed = txn.Gas
+		return contract.ErrOutOfGas
+	}
+	receipt.GasUsed = TransferGas
+
+	err := c.transfer(txn.From, txn.To, txn.Value)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Transferred %d to %s\n", txn.Value, hex.EncodeToString(txn.To))
+	return nil
+}
+
+// executeCall calls the recipient contract. The value of the transaction is
+// only moved to the contract if the call succeeds.
+func (c *Chain) executeCall(txn *Transaction, receipt *Receipt) error {
+	var contractID [32]byte
+	copy(contractID[:], txn.To)
+
+	fmt.Printf("Calling Contract ID: %s\n", hex.EncodeToString(contractID[:]))
+
+	env, err := c.environment(txn)
+	if err != nil {
+		return err
+	}
+
+	result, gas, err := c.engine.Call(contractID, env, txn.Gas, txn.Data)
+	receipt.GasUsed = gas
+	if err != nil {
+		return err
+	}
+	fmt.Printf("result: %s, gas: %d\n", hex.EncodeToString(result), gas)
+	receipt.Result = result
+
+	for _, e := range env.Events {
+		fmt.Printf("📣 Event %s: %s\n", string(e.Topic), hex.EncodeToString(e.Data))
+		contractID := e.Contract
+		receipt.Events = append(receipt.Events, &Event{
+			Type:          Event_CONTRACT_EVENT,
+			TransactionId: txn.Id,
+			From:          txn.From,
+			Contract:      contractID[:],
+			Topic:         e.Topic,
+			Data:          e.Data,
+		})
+	}
+
+	return c.transfer(txn.From, txn.To, txn.Value)
+}
+
+// environment returns the context a contract is called in by the transaction
+func (c *Chain) environment(txn *Transaction) (*contract.Environment, error) {
+	blueScore, err := c.graph.BlueScore(string(txn.Id))
+	if err != nil {
+		return nil, err
+	}
+	height, err := c.graph.Height(string(txn.Id))
+	if err != nil {
+		return nil, err
+	}
+
+	return &contract.Environment{
+		Caller:        txn.From,
+		TransactionID: txn.Id,
+		BlueScore:     int64(blueScore),
+		Height:        int64(height),
+	}, nil
+}
+
+// getTransaction reads a transaction from the database by id
+func (c *Chain) getTransaction(id []byte) (*Transaction, error) {
+	txnBytes, err := c.db.Get([]byte(DatabaseNamespace), id)
+	if err != nil {
+		return nil, err
+	}
+
+	txn := &Transaction{}
+	err = proto.Unmarshal(txnBytes, txn)
+	if err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
@@ -0,0 +1,278 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sporeframework/spore/config"
+	"github.com/sporeframework/spore/db"
+	"google.golang.org/protobuf/proto"
+)
+
+// newTestChain returns a chain on a new database that executes transactions
+// as soon as they are added, and a function removing it
+func newTestChain(t *testing.T) (*Chain, func()) {
+	dir, err := ioutil.TempDir("", "chain")
+	if err != nil {
+		t.Fatalf("failed to create directory: %s", err)
+	}
+	database, err := db.NewBadgerDB(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("failed to open database: %s", err)
+	}
+
+	conf := config.Default()
+	conf.DAG.ConfirmationDepth = 0
+	c, err := NewChain(database, conf)
+	if err != nil {
+		database.Close()
+		os.RemoveAll(dir)
+		t.Fatalf("failed to load chain: %s", err)
+	}
+	return c, func() {
+		database.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// transferTo returns a signed transfer of nothing with the given parents
+func transferTo(t *testing.T, parents ...*Transaction) *Transaction {
+	txn := &Transaction{To: make([]byte, AddressLength), Gas: TransferGas}
+	for _, p := range parents {
+		txn.Parents = append(txn.Parents, p.Id)
+	}
+	return signedRequest(t, txn).Transaction
+}
+
+func TestExecuteConfirmed_LateBranch(t *testing.T) {
+	c, cleanup := newTestChain(t)
+	defer cleanup()
+
+	genesis := transferTo(t)
+	c.AddBlock(genesis)
+	c.AddBlock(transferTo(t, genesis))
+
+	// branches merged late, as through sync after a partition, are ordered
+	// among transactions executed already
+	merged := false
+	for i := 0; i < 16; i++ {
+		c.AddBlock(transferTo(t, genesis))
+
+		order, err := c.graph.Order()
+		if err != nil {
+			t.Fatalf("failed to order DAG: %s", err)
+		}
+		if c.executedIndex != uint64(len(order)) {
+			t.Fatalf("expected %d executed transactions, got %d", len(order), c.executedIndex)
+		}
+		if c.executedPrefix != len(order) {
+			t.Errorf("expected the walk to resume past %d executed transactions, got %d", len(order), c.executedPrefix)
+		}
+
+		indexes := make(map[uint64]bool)
+		for position, id := range order {
+			receipt, err := c.getReceipt([]byte(id))
+			if err != nil || receipt == nil {
+				t.Fatalf("expected transaction %x to be executed, got %v", id, err)
+			}
+			if receipt.Status != Receipt_SUCCESS {
+				t.Errorf("expected transaction %x to be executed once, got %s", id, receipt.Error)
+			}
+			if indexes[receipt.Index] {
+				t.Errorf("expected execution sequence number %d to be used once", receipt.Index)
+			}
+			indexes[receipt.Index] = true
+			merged = merged || receipt.Index > uint64(position)
+		}
+	}
+	if !merged {
+		t.Error("expected a late branch to be ordered before executed transactions")
+	}
+}
+
+func TestExecuteConfirmed_Restart(t *testing.T) {
+	c, cleanup := newTestChain(t)
+	defer cleanup()
+
+	genesis := transferTo(t)
+	c.AddBlock(genesis)
+	c.AddBlock(transferTo(t, genesis))
+
+	restarted, err := NewChain(c.db, c.conf)
+	if err != nil {
+		t.Fatalf("failed to reload chain: %s", err)
+	}
+	if restarted.executedIndex != 2 || len(restarted.executed) != 2 {
+		t.Errorf("expected 2 executed transactions after restart, got %d", restarted.executedIndex)
+	}
+}
+
+// signedBy signs a transaction with the key and sets its metadata
+func signedBy(t *testing.T, prv *ecdsa.PrivateKey, txn *Transaction) *Transaction {
+	var err error
+	txn.From = crypto.PubkeyToAddress(prv.PublicKey).Bytes()
+	txn.Signature, err = crypto.Sign(TransactionHash(txn), prv)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %s", err)
+	}
+	setMetadata(txn)
+	return txn
+}
+
+func TestExecuteConfirmed_Deferred(t *testing.T) {
+	c, cleanup := newTestChain(t)
+	defer cleanup()
+	prv, _ := crypto.GenerateKey()
+
+	genesis := transferTo(t)
+	c.AddBlock(genesis)
+
+	// the second transaction of the account is ordered first
+	second := signedBy(t, prv, &Transaction{To: make([]byte, AddressLength), Gas: TransferGas, Nonce: 1, Parents: [][]byte{genesis.Id}})
+	c.AddBlock(second)
+	if receipt, _ := c.getReceipt(second.Id); receipt != nil {
+		t.Fatalf("expected transaction ahead of its nonce to be deferred, got %v", receipt)
+	}
+
+	first := signedBy(t, prv, &Transaction{To: make([]byte, AddressLength), Gas: TransferGas, Parents: [][]byte{second.Id}})
+	c.AddBlock(first)
+	for _, txn := range []*Transaction{first, second} {
+		receipt, err := c.getReceipt(txn.Id)
+		if err != nil || receipt == nil || receipt.Status != Receipt_SUCCESS {
+			t.Errorf("expected transaction with nonce %d to be executed, got %v %v", txn.Nonce, receipt, err)
+		}
+	}
+}
+
+func TestExecute_Underfunded(t *testing.T) {
+	c, cleanup := newTestChain(t)
+	defer cleanup()
+	prv, _ := crypto.GenerateKey()
+
+	genesis := transferTo(t)
+	c.AddBlock(genesis)
+
+	// an underfunded transaction is skipped without using its nonce, and the
+	// transaction after it waits
+	underfunded := signedBy(t, prv, &Transaction{To: make([]byte, AddressLength), Gas: TransferGas, GasPrice: 1, Parents: [][]byte{genesis.Id}})
+	c.AddBlock(underfunded)
+	second := signedBy(t, prv, &Transaction{To: make([]byte, AddressLength), Gas: TransferGas, Nonce: 1, Parents: [][]byte{underfunded.Id}})
+	c.AddBlock(second)
+	receipt, err := c.getReceipt(underfunded.Id)
+	if err != nil || receipt == nil || receipt.Status == Receipt_SUCCESS {
+		t.Fatalf("expected underfunded transaction to fail, got %v %v", receipt, err)
+	}
+	if nonce, _ := c.getAccountNonce(underfunded.From); nonce != 0 {
+		t.Errorf("expected underfunded transaction to leave nonce 0, got %d", nonce)
+	}
+	if receipt, _ := c.getReceipt(second.Id); receipt != nil {
+		t.Errorf("expected transaction after the underfunded one to wait, got %v", receipt)
+	}
+
+	// another transaction with the nonce executes, then the one waiting
+	first := signedBy(t, prv, &Transaction{To: make([]byte, AddressLength), Gas: TransferGas, Parents: [][]byte{second.Id}})
+	c.AddBlock(first)
+	for _, txn := range []*Transaction{first, second} {
+		receipt, err := c.getReceipt(txn.Id)
+		if err != nil || receipt == nil || receipt.Status != Receipt_SUCCESS {
+			t.Errorf("expected transaction with nonce %d to be executed, got %v %v", txn.Nonce, receipt, err)
+		}
+	}
+}
+
+func TestTransfer_Overflow(t *testing.T) {
+	c, cleanup := newTestChain(t)
+	defer cleanup()
+	from, to := []byte("sender address......"), []byte("rich address........")
+	c.setBalance(from, 10)
+	c.setBalance(to, math.MaxInt64)
+
+	// a credit that would overflow fails before the sender is debited
+	if err := c.transfer(from, to, 5); err == nil {
+		t.Fatal("expected overflowing transfer to fail")
+	}
+	if balance, _ := c.getBalance(from); balance != 10 {
+		t.Errorf("expected sender to keep 10, got %d", balance)
+	}
+	if err := c.transfer(from, from, 10); err != nil {
+		t.Fatalf("failed to transfer to self: %s", err)
+	}
+	if balance, _ := c.getBalance(from); balance != 10 {
+		t.Errorf("expected transfer to self to keep 10, got %d", balance)
+	}
+}
+
+func TestPendingNonce(t *testing.T) {
+	c, cleanup := newTestChain(t)
+	defer cleanup()
+	c.conf.RPC.MinGasPrice = 0
+	s := NewServer(c)
+	prv, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(prv.PublicKey).Bytes()
+
+	pendingNonce := func() int32 {
+		nonce, err := s.GetAccountNonce(context.Background(), &Account{Address: address})
+		if err != nil {
+			t.Fatalf("failed to get nonce: %s", err)
+		}
+		return nonce.PendingNonce
+	}
+
+	// a transaction that is not published does not hold its nonce
+	txn := signedBy(t, prv, &Transaction{To: make([]byte, AddressLength), Gas: TransferGas})
+	if _, err := s.Send(context.Background(), txn); err == nil {
+		t.Fatal("expected send without a network to fail")
+	}
+	if nonce := pendingNonce(); nonce != 0 {
+		t.Fatalf("expected nonce of unpublished transaction to be released, got pending nonce %d", nonce)
+	}
+
+	// transactions received from the network count, wherever they wait
+	if err := c.addPending(txn, ""); err != nil {
+		t.Fatalf("failed to add pending transaction: %s", err)
+	}
+	if nonce := pendingNonce(); nonce != 1 {
+		t.Errorf("expected pending nonce 1 with a transaction in the mempool, got %d", nonce)
+	}
+	next := signedBy(t, prv, &Transaction{To: make([]byte, AddressLength), Gas: TransferGas, Nonce: 1, Parents: [][]byte{[]byte("missing parent of 32 bytes......")}})
+	c.AddBlock(next)
+	if nonce := pendingNonce(); nonce != 2 {
+		t.Errorf("expected pending nonce 2 with a transaction waiting for its parent, got %d", nonce)
+	}
+
+	// an evicted transaction no longer holds its nonce
+	c.pool.Take(1)
+	if nonce := pendingNonce(); nonce != 0 {
+		t.Errorf("expected pending nonce 0 once the mempool is empty, got %d", nonce)
+	}
+}
+
+func TestSend_Retry(t *testing.T) {
+	c, cleanup := newTestChain(t)
+	defer cleanup()
+	c.conf.RPC.MinGasPrice = 0
+	s := NewServer(c)
+	prv, _ := crypto.GenerateKey()
+
+	// the transaction was published, but the response to the client was lost
+	txn := signedBy(t, prv, &Transaction{To: make([]byte, AddressLength), Gas: TransferGas})
+	if err := c.addPending(proto.Clone(txn).(*Transaction), ""); err != nil {
+		t.Fatalf("failed to add pending transaction: %s", err)
+	}
+
+	// without a network, the retry only succeeds if it is not published again
+	resp, err := s.Send(context.Background(), proto.Clone(txn).(*Transaction))
+	if err != nil || !bytes.Equal(resp.GetTransactionId(), txn.Id) {
+		t.Fatalf("expected retried send to succeed, got %v", err)
+	}
+	if results := c.sendBatch([]*Transaction{proto.Clone(txn).(*Transaction)}); results[0].Error != "" {
+		t.Errorf("expected retried batch to succeed, got %s", results[0].Error)
+	}
+}
@@ -0,0 +1,324 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+const MempoolNamespace = "sporemempool"
+
+var (
+	// pendingPrefix prefixes the key of each pending transaction, followed by
+	// its id
+	pendingPrefix = []byte("pending/")
+
+	errDuplicateTransaction = errors.New("transaction already known")
+	errMempoolFull          = errors.New("mempool is full of transactions with a higher priority")
+)
+
+// Mempool is a bounded pool of pending transactions ordered by priority:
+// highest gas price first, then lowest nonce, then id
+type Mempool struct {
+	mu      sync.Mutex
+	size    int
+	txns    map[string]*Transaction
+	sources map[string]peer.ID
+	nonces  nonceIndex
+	// changed holds the ids of the transactions added or removed since the
+	// last call to Changes
+	changed map[string]struct{}
+}
+
+// NewMempool returns an empty mempool that holds up to size transactions
+func NewMempool(size int) *Mempool {
+	return &Mempool{
+		size:    size,
+		txns:    make(map[string]*Transaction),
+		sources: make(map[string]peer.ID),
+		nonces:  make(nonceIndex),
+		changed: make(map[string]struct{}),
+	}
+}
+
+// higherPriority reports whether a goes before b
+func higherPriority(a, b *Transaction) bool {
+	if a.GasPrice != b.GasPrice {
+		return a.GasPrice > b.GasPrice
+	}
+	if a.Nonce != b.Nonce {
+		return a.Nonce < b.Nonce
+	}
+	return bytes.Compare(a.Id, b.Id) < 0
+}
+
+// Add adds a transaction of this node to the pool. If the pool is full, the
+// transaction with the lowest priority is evicted to make room, unless that is
+// the new transaction.
+func (m *Mempool) Add(txn *Transaction) error {
+	return m.AddFrom(txn, "")
+}
+
+// AddFrom adds a transaction received from a peer to the pool, like Add
+func (m *Mempool) AddFrom(txn *Transaction, from peer.ID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := string(txn.Id)
+	if _, ok := m.txns[id]; ok {
+		return errDuplicateTransaction
+	}
+
+	if len(m.txns) >= m.size {
+		var lowest *Transaction
+		for _, pending := range m.txns {
+			if lowest == nil || higherPriority(lowest, pending) {
+				lowest = pending
+			}
+		}
+		if lowest == nil || higherPriority(lowest, txn) {
+			return errMempoolFull
+		}
+		log.Debugf("evicting transaction %x from the mempool", lowest.Id)
+		delete(m.txns, string(lowest.Id))
+		delete(m.sources, string(lowest.Id))
+		m.nonces.remove(lowest)
+		m.changed[string(lowest.Id)] = struct{}{}
+	}
+
+	m.txns[id] = txn
+	m.sources[id] = from
+	m.nonces.add(txn)
+	m.changed[id] = struct{}{}
+	return nil
+}
+
+// Get returns the pending transaction with the id, or nil
+func (m *Mempool) Get(id []byte) *Transaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.txns[string(id)]
+}
+
+// HasNonce reports whether a pending transaction of the account uses the nonce
+func (m *Mempool) HasNonce(address []byte, nonce int32) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.nonces.has(address, nonce)
+}
+
+// Len returns the number of pending transactions
+func (m *Mempool) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.txns)
+}
+
+// Pending returns up to limit pending transactions in priority order, only
+// those sent by from if it is set. A limit of 0 returns all of them.
+func (m *Mempool) Pending(from []byte, limit int) []*Transaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pending(from, limit)
+}
+
+// pending implements Pending. The caller must hold mu.
+func (m *Mempool) pending(from []byte, limit int) []*Transaction {
+	txns := make([]*Transaction, 0, len(m.txns))
+	for _, txn := range m.txns {
+		if len(from) == 0 || bytes.Equal(from, txn.From) {
+			txns = append(txns, txn)
+		}
+	}
+	sort.Slice(txns, func(i, j int) bool {
+		return higherPriority(txns[i], txns[j])
+	})
+
+	if limit > 0 && len(txns) > limit {
+		txns = txns[:limit]
+	}
+	return txns
+}
+
+// Take removes and returns up to n transactions with the highest priority
+func (m *Mempool) Take(n int) []*Transaction {
+	txns, _ := m.TakeFrom(n)
+	return txns
+}
+
+// TakeFrom is like Take, and also returns the peer each transaction was
+// received from
+func (m *Mempool) TakeFrom(n int) ([]*Transaction, []peer.ID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	txns := m.pending(nil, n)
+	sources := make([]peer.ID, len(txns))
+	for i, txn := range txns {
+		sources[i] = m.sources[string(txn.Id)]
+		delete(m.txns, string(txn.Id))
+		delete(m.sources, string(txn.Id))
+		m.nonces.remove(txn)
+		m.changed[string(txn.Id)] = struct{}{}
+	}
+	return txns, sources
+}
+
+// Changes returns the transactions added to the pool and the ids of those
+// removed from it since the last call
+func (m *Mempool) Changes() ([]*Transaction, []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var added []*Transaction
+	var removed []string
+	for id := range m.changed {
+		if txn, ok := m.txns[id]; ok {
+			added = append(added, txn)
+		} else {
+			removed = append(removed, id)
+		}
+	}
+	m.changed = make(map[string]struct{})
+	return added, removed
+}
+
+// markChanged reports the transactions with the ids as changed again by the
+// next call to Changes
+func (m *Mempool) markChanged(ids []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range ids {
+		m.changed[id] = struct{}{}
+	}
+}
+
+// addPending validates a transaction received from the network, from the peer
+// from, and adds it to the mempool, unless it is already in the DAG or waiting
+// for its parents. It runs the fee and balance checks of RPC ingress, so that
+// peers cannot fill the mempool with transactions that can never pay.
+func (c *Chain) addPending(txn *Transaction, from peer.ID) error {
+	if txn == nil {
+		return errors.New("request has no transaction")
+	}
+
+	err := verifyTransaction(txn)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	exists, _ := c.graph.NodeExists(string(txn.Id))
+	if exists || c.orphans.has(string(txn.Id)) {
+		return errDuplicateTransaction
+	}
+
+	// the nonce reserved at ingress is now held by the mempool, or released
+	// if the transaction is rejected
+	err = c.checkFeeLocked(txn)
+	if err == nil {
+		err = c.pool.AddFrom(txn, from)
+	}
+	c.unreserve(txn.Id)
+	return err
+}
+
+// loadMempool restores the transactions pending before the last shutdown
+func (c *Chain) loadMempool() error {
+	var pending []*Transaction
+	err := c.db.Iterate([]byte(MempoolNamespace), pendingPrefix, func(key, value []byte) error {
+		txn := &Transaction{}
+		if err := proto.Unmarshal(value, txn); err != nil {
+			return fmt.Errorf("invalid pending transaction %x: %s", key[len(pendingPrefix):], err)
+		}
+		pending = append(pending, txn)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, len(pending))
+	for i, txn := range pending {
+		ids[i] = string(txn.Id)
+		err = c.addPending(txn, "")
+		if err != nil {
+			log.Debugf("dropping pending transaction %x: %s", txn.Id, err)
+		}
+	}
+	fmt.Println("Loaded pending transactions: ", c.pool.Len())
+
+	// delete the keys of the transactions dropped
+	c.pool.Changes()
+	c.pool.markChanged(ids)
+	return c.storeMempool()
+}
+
+// storeMempool persists the changes of the pending transactions, each under
+// its own key
+func (c *Chain) storeMempool() error {
+	added, removed := c.pool.Changes()
+	var err error
+	for _, txn := range added {
+		var data []byte
+		data, err = proto.Marshal(txn)
+		if err == nil {
+			err = c.db.Set([]byte(MempoolNamespace), pendingKey(txn.Id), data)
+		}
+		if err != nil {
+			break
+		}
+	}
+	for _, id := range removed {
+		if err == nil {
+			err = c.db.Delete([]byte(MempoolNamespace), pendingKey([]byte(id)))
+		}
+	}
+
+	// the changes are stored again on the next call
+	if err != nil {
+		for _, txn := range added {
+			removed = append(removed, string(txn.Id))
+		}
+		c.pool.markChanged(removed)
+	}
+	return err
+}
+
+// pendingKey returns the key of the pending transaction with the id
+func pendingKey(id []byte) []byte {
+	return append(append([]byte{}, pendingPrefix...), id...)
+}
+
+// runMempool moves pending transactions into the DAG, at most
+// mempool.batchSize every mempool.interval, and persists the pool as it
+// changes, until ctx is done
+func (c *Chain) runMempool(ctx context.Context) {
+	ticker := time.NewTicker(c.conf.Mempool.Interval.Duration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			txns, sources := c.pool.TakeFrom(c.conf.Mempool.BatchSize)
+			for i, txn := range txns {
+				c.addBlockFrom(txn, sources[i])
+			}
+
+			if err := c.storeMempool(); err != nil {
+				log.Errorf("❌ failed to persist mempool: %s", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package protocol
+
+import "testing"
+
+func pendingTxn(id string, gasPrice int64, nonce int32) *Transaction {
+	return &Transaction{Id: []byte(id), GasPrice: gasPrice, Nonce: nonce, From: []byte("sender")}
+}
+
+func TestMempool_Priority(t *testing.T) {
+	m := NewMempool(10)
+	m.Add(pendingTxn("a", 1, 0))
+	m.Add(pendingTxn("b", 5, 1))
+	m.Add(pendingTxn("c", 5, 0))
+	m.Add(pendingTxn("d", 2, 0))
+
+	expected := []string{"c", "b", "d", "a"}
+	pending := m.Pending(nil, 0)
+	if len(pending) != len(expected) {
+		t.Fatalf("expected %d pending transactions, got %d", len(expected), len(pending))
+	}
+	for i, txn := range pending {
+		if string(txn.Id) != expected[i] {
+			t.Errorf("expected %s at position %d, got %s", expected[i], i, txn.Id)
+		}
+	}
+
+	taken := m.Take(2)
+	if len(taken) != 2 || string(taken[0].Id) != "c" || string(taken[1].Id) != "b" {
+		t.Errorf("took the wrong transactions: %v", taken)
+	}
+	if m.Len() != 2 {
+		t.Errorf("expected 2 transactions left, got %d", m.Len())
+	}
+}
+
+func TestMempool_Deduplicate(t *testing.T) {
+	m := NewMempool(10)
+	if err := m.Add(pendingTxn("a", 1, 0)); err != nil {
+		t.Fatalf("failed to add transaction: %s", err)
+	}
+	if err := m.Add(pendingTxn("a", 1, 0)); err != errDuplicateTransaction {
+		t.Errorf("expected duplicate error, got %v", err)
+	}
+	if m.Get([]byte("a")) == nil || m.Len() != 1 {
+		t.Error("expected a single pending transaction")
+	}
+}
+
+func TestMempool_Evict(t *testing.T) {
+	m := NewMempool(2)
+	m.Add(pendingTxn("a", 2, 0))
+	m.Add(pendingTxn("b", 3, 0))
+
+	// the lowest priority transaction is rejected when the pool is full
+	if err := m.Add(pendingTxn("c", 1, 0)); err != errMempoolFull {
+		t.Errorf("expected mempool full error, got %v", err)
+	}
+
+	// a higher priority one evicts the lowest
+	if err := m.Add(pendingTxn("d", 4, 0)); err != nil {
+		t.Fatalf("failed to add transaction: %s", err)
+	}
+	if m.Get([]byte("a")) != nil {
+		t.Error("expected lowest priority transaction to be evicted")
+	}
+	if m.Len() != 2 {
+		t.Errorf("expected 2 pending transactions, got %d", m.Len())
+	}
+}
+
+func TestMempool_Changes(t *testing.T) {
+	m := NewMempool(1)
+	if added, removed := m.Changes(); len(added) != 0 || len(removed) != 0 {
+		t.Error("expected empty mempool to be unchanged")
+	}
+
+	m.Add(pendingTxn("a", 1, 0))
+	m.Add(pendingTxn("b", 2, 0))
+	added, removed := m.Changes()
+	if len(added) != 1 || string(added[0].Id) != "b" || len(removed) != 1 || removed[0] != "a" {
+		t.Fatalf("expected b added and evicted a removed, got %v %v", added, removed)
+	}
+	if added, removed = m.Changes(); len(added) != 0 || len(removed) != 0 {
+		t.Error("expected mempool to be unchanged since the last call")
+	}
+
+	m.Take(1)
+	if added, removed = m.Changes(); len(added) != 0 || len(removed) != 1 || removed[0] != "b" {
+		t.Errorf("expected taken transaction to be removed, got %v %v", added, removed)
+	}
+}
+
+func TestMempool_Persist(t *testing.T) {
+	c, cleanup := newTestChain(t)
+	defer cleanup()
+
+	a := signedRequest(t, &Transaction{To: make([]byte, AddressLength), Gas: TransferGas}).Transaction
+	b := signedRequest(t, &Transaction{To: make([]byte, AddressLength), Gas: TransferGas, GasPrice: 1}).Transaction
+	if err := c.setBalance(b.From, TransferGas); err != nil {
+		t.Fatalf("failed to fund sender: %s", err)
+	}
+	for _, txn := range []*Transaction{a, b} {
+		if err := c.addPending(txn, ""); err != nil {
+			t.Fatalf("failed to add pending transaction: %s", err)
+		}
+	}
+	if err := c.storeMempool(); err != nil {
+		t.Fatalf("failed to store mempool: %s", err)
+	}
+	for _, txn := range []*Transaction{a, b} {
+		if ok, _ := c.db.Has([]byte(MempoolNamespace), pendingKey(txn.Id)); !ok {
+			t.Fatalf("expected transaction %x to be stored under its own key", txn.Id)
+		}
+	}
+
+	c.pool.Take(1)
+	if err := c.storeMempool(); err != nil {
+		t.Fatalf("failed to store mempool: %s", err)
+	}
+	if ok, _ := c.db.Has([]byte(MempoolNamespace), pendingKey(b.Id)); ok {
+		t.Error("expected key of taken transaction to be deleted")
+	}
+
+	restarted, err := NewChain(c.db, c.conf)
+	if err != nil {
+		t.Fatalf("failed to reload chain: %s", err)
+	}
+	if restarted.pool.Len() != 1 || restarted.pool.Get(a.Id) == nil {
+		t.Errorf("expected the pending transaction to be restored, got %d", restarted.pool.Len())
+	}
+}
+
+func TestAddPending_Fee(t *testing.T) {
+	c, cleanup := newTestChain(t)
+	defer cleanup()
+	c.conf.RPC.MinGasPrice = 1
+
+	for name, txn := range map[string]*Transaction{
+		"no gas":            {To: make([]byte, AddressLength), GasPrice: 1},
+		"low gas price":     {To: make([]byte, AddressLength), Gas: TransferGas},
+		"unfunded sender":   {To: make([]byte, AddressLength), Gas: TransferGas, GasPrice: 1},
+		"fee overflow":      {To: make([]byte, AddressLength), Gas: TransferGas, GasPrice: 1 << 62},
+		"unfunded transfer": {To: make([]byte, AddressLength), Gas: TransferGas, GasPrice: 1, Value: 1},
+	} {
+		txn = signedRequest(t, txn).Transaction
+		if err := c.addPending(txn, "peer"); err == nil {
+			t.Errorf("expected transaction with %s to be rejected", name)
+		}
+		if c.pool.Get(txn.Id) != nil {
+			t.Errorf("expected transaction with %s not to be pending", name)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package protocol
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const (
+	// maxOrphans is the most transactions held waiting for their parents
+	maxOrphans = 4096
+	// maxOrphansPerPeer is the most transactions held waiting for their
+	// parents that were received from a single peer
+	maxOrphansPerPeer = 256
+	// orphanTTL is how long a transaction waits for its parents before it is
+	// dropped
+	orphanTTL = 10 * time.Minute
+)
+
+// orphan is a transaction waiting for a parent missing from the graph
+type orphan struct {
+	txn     *Transaction
+	from    peer.ID
+	parent  string
+	expires time.Time
+}
+
+// orphanPool holds the transactions whose parents are not all in the graph
+// yet, by the id of the parent they wait for. It is bounded per peer and in
+// total, and drops transactions waiting longer than orphanTTL, so that peers
+// cannot fill it with transactions referencing made-up parents.
+type orphanPool struct {
+	byID     map[string]*orphan
+	byParent map[string]map[string]struct{}
+	byPeer   map[peer.ID]int
+}
+
+func newOrphanPool() *orphanPool {
+	return &orphanPool{
+		byID:     make(map[string]*orphan),
+		byParent: make(map[string]map[string]struct{}),
+		byPeer:   make(map[peer.ID]int),
+	}
+}
+
+// has reports whether the transaction with the id is waiting for a parent
+func (p *orphanPool) has(id string) bool {
+	_, ok := p.byID[id]
+	return ok
+}
+
+// len returns the number of waiting transactions
+func (p *orphanPool) len() int {
+	return len(p.byID)
+}
+
+// add holds a transaction received from a peer until its parent arrives. It
+// returns the transactions dropped to make room: the oldest one if the pool
+// is full, or txn itself if the peer already has maxOrphansPerPeer waiting.
+func (p *orphanPool) add(txn *Transaction, from peer.ID, parent string) []*Transaction {
+	id := string(txn.Id)
+	if p.has(id) {
+		return nil
+	}
+	if p.byPeer[from] >= maxOrphansPerPeer {
+		return []*Transaction{txn}
+	}
+
+	var dropped []*Transaction
+	if len(p.byID) >= maxOrphans {
+		var oldest *orphan
+		for _, o := range p.byID {
+			if oldest == nil || o.expires.Before(oldest.expires) {
+				oldest = o
+			}
+		}
+		p.remove(oldest)
+		dropped = append(dropped, oldest.txn)
+	}
+
+	p.byID[id] = &orphan{txn: txn, from: from, parent: parent, expires: time.Now().Add(orphanTTL)}
+	if p.byParent[parent] == nil {
+		p.byParent[parent] = make(map[string]struct{})
+	}
+	p.byParent[parent][id] = struct{}{}
+	p.byPeer[from]++
+	return dropped
+}
+
+// take removes and returns the transactions waiting for the parent
+func (p *orphanPool) take(parent string) []*orphan {
+	waiting := make([]*orphan, 0, len(p.byParent[parent]))
+	for id := range p.byParent[parent] {
+		waiting = append(waiting, p.byID[id])
+	}
+	for _, o := range waiting {
+		p.remove(o)
+	}
+	return waiting
+}
+
+// expire removes and returns the transactions that waited longer than
+// orphanTTL
+func (p *orphanPool) expire() []*Transaction {
+	var expired []*Transaction
+	now := time.Now()
+	for _, o := range p.byID {
+		if now.After(o.expires) {
+			p.remove(o)
+			expired = append(expired, o.txn)
+		}
+	}
+	return expired
+}
+
+func (p *orphanPool) remove(o *orphan) {
+	id := string(o.txn.Id)
+	delete(p.byID, id)
+	delete(p.byParent[o.parent], id)
+	if len(p.byParent[o.parent]) == 0 {
+		delete(p.byParent, o.parent)
+	}
+	p.byPeer[o.from]--
+	if p.byPeer[o.from] == 0 {
+		delete(p.byPeer, o.from)
+	}
+}
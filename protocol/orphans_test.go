@@ -0,0 +1,69 @@
+package protocol
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func orphanTxn(i int) *Transaction {
+	return &Transaction{Id: []byte(fmt.Sprintf("orphan %d", i)), From: []byte("sender")}
+}
+
+func TestOrphanPool_Bounds(t *testing.T) {
+	p := newOrphanPool()
+
+	// a single peer cannot fill the pool
+	for i := 0; i < maxOrphansPerPeer; i++ {
+		if dropped := p.add(orphanTxn(i), "spammer", "made-up parent"); len(dropped) != 0 {
+			t.Fatalf("expected orphan %d to be held, got %d dropped", i, len(dropped))
+		}
+	}
+	if dropped := p.add(orphanTxn(maxOrphansPerPeer), "spammer", "made-up parent"); len(dropped) != 1 || p.has(string(dropped[0].Id)) {
+		t.Fatalf("expected orphan past the peer limit to be dropped, got %v", dropped)
+	}
+
+	// a full pool drops its oldest transaction
+	for i := maxOrphansPerPeer; p.len() < maxOrphans; i++ {
+		p.add(orphanTxn(i), peer.ID(fmt.Sprintf("peer %d", i/maxOrphansPerPeer)), "made-up parent")
+	}
+	p.byID[string(orphanTxn(0).Id)].expires = time.Now()
+	dropped := p.add(orphanTxn(-1), "other", "parent")
+	if len(dropped) != 1 || string(dropped[0].Id) != string(orphanTxn(0).Id) || p.len() != maxOrphans {
+		t.Fatalf("expected the oldest orphan to be dropped, got %v", dropped)
+	}
+
+	if waiting := p.take("parent"); len(waiting) != 1 || waiting[0].from != "other" {
+		t.Errorf("expected the orphan waiting for the parent, got %v", waiting)
+	}
+
+	for _, o := range p.byID {
+		o.expires = time.Now().Add(-time.Second)
+	}
+	if expired := p.expire(); len(expired) != maxOrphans-1 || p.len() != 0 || len(p.byParent) != 0 || len(p.byPeer) != 0 {
+		t.Errorf("expected every orphan to expire, got %d expired and %d left", len(expired), p.len())
+	}
+}
+
+func TestAddBlock_OrphanExpiry(t *testing.T) {
+	c, cleanup := newTestChain(t)
+	defer cleanup()
+
+	genesis := transferTo(t)
+	c.AddBlock(genesis)
+	orphan := transferTo(t, &Transaction{Id: []byte("missing parent of 32 bytes......")})
+	c.AddBlock(orphan)
+	if !c.orphans.has(string(orphan.Id)) || !c.unexecuted.has(orphan.From, orphan.Nonce) {
+		t.Fatal("expected transaction to wait for its parent")
+	}
+
+	c.orphans.byID[string(orphan.Id)].expires = time.Now().Add(-time.Second)
+	c.mu.Lock()
+	c.expireOrphans()
+	c.mu.Unlock()
+	if c.orphans.has(string(orphan.Id)) || c.unexecuted.has(orphan.From, orphan.Nonce) {
+		t.Error("expected expired transaction to be dropped")
+	}
+}
@@ -5,80 +5,95 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
-	"sync"
 
-	"github.com/kirsle/configdir"
 	"github.com/libp2p/go-libp2p-core/peer"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 
 	log "github.com/sirupsen/logrus"
-	"github.com/sporeframework/spore/contract"
-	"github.com/sporeframework/spore/dag"
-	"github.com/sporeframework/spore/db"
 	"google.golang.org/protobuf/proto"
 )
 
 const (
 	PubsubTopic       = "/spore/1.0.0"
 	DatabaseNamespace = "sporedb"
+	DagNamespace      = "sporedag"
+	ContractNamespace = "sporecontract"
 )
 
-var (
-	g        *dag.GreedyGraphMem
-	Database db.DB
-	eng      *contract.ContractEngine
-	mu       sync.Mutex
-)
+// AddBlock adds a transaction to the DAG and executes the transactions it
+// confirms
+func (c *Chain) AddBlock(txn *Transaction) {
+	c.addBlockFrom(txn, "")
+}
 
-func InitializeChain() {
-	var k = 1621
-	graph, err := dag.NewGreedyGraphMem(k)
-	if err != nil {
-		log.Error("failed to create new GreedyGraphMem: %s", err)
-	}
-	g = graph
+// addBlockFrom adds a transaction received from a peer, or from this node if
+// from is empty, to the DAG and executes the transactions it confirms
+func (c *Chain) addBlockFrom(txn *Transaction, from peer.ID) {
 
-	// startup the db
-	// BadgerDB database
-	dbPath := configdir.LocalConfig("spore", "db")
-	err = configdir.MakePath(dbPath) // Ensure it exists.
-	if err != nil {
-		panic(err)
-	}
-	Database, err = db.NewBadgerDB(dbPath)
-	if err != nil {
-		panic(err)
-	}
-	engine, err := contract.NewContractEngine()
-	if err != nil {
-		panic(err)
-	}
-	eng = engine
+	// this is only really required when using ordering
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.addBlock(txn, from)
+	c.executeConfirmed()
 }
 
-func AddBlock(txn *Transaction) {
+// addBlock adds the transaction to the DAG under its declared parents. If any
+// parent is not in the graph yet, the transaction is held back until it arrives,
+// so that every node builds the same DAG regardless of gossip order.
+func (c *Chain) addBlock(txn *Transaction, from peer.ID) {
+	id := string(txn.Id)
 
-	// this is only really required when using ordering
-	mu.Lock()
-	defer mu.Unlock()
+	// re-adding a known node would recolor it, diverging from the persisted graph
+	if exists, _ := c.graph.NodeExists(id); exists {
+		log.Warnf("node %x already in graph", txn.Id)
+		return
+	}
+	if c.orphans.has(id) {
+		return
+	}
+	if err := c.checkRoot(txn); err != nil {
+		log.Warnf("node %x rejected: %s", txn.Id, err)
+		return
+	}
 
-	tips, err := g.Tips()
-	if err != nil {
-		fmt.Errorf("❌ failed to get tips: %s", err)
+	parents := make([]string, len(txn.Parents))
+	for i, p := range txn.Parents {
+		parents[i] = string(p)
+		if exists, _ := c.graph.NodeExists(parents[i]); !exists {
+			c.expireOrphans()
+			for _, dropped := range c.orphans.add(txn, from, parents[i]) {
+				log.Infof("dropping node %x waiting for its parents", dropped.Id)
+				c.unexecuted.remove(dropped)
+			}
+			if c.orphans.has(id) {
+				log.Infof("node %x waiting for parent %x", txn.Id, p)
+				c.unexecuted.add(txn)
+			}
+			return
+		}
 	}
 
-	ok, err := g.Add(string(txn.Id), tips)
+	// write transaction to the database before it becomes part of the graph,
+	// so that every persisted node can be loaded for execution
+	c.set(txn)
+
+	ok, err := c.graph.Add(id, parents)
 	if err != nil {
-		fmt.Errorf("❌ failed to add node %s: %s", txn.Id, err)
+		log.Errorf("❌ failed to add node %x: %s", txn.Id, err)
 	}
 
 	if !ok {
-		fmt.Errorf("❌ node %s not added to graph", txn.Id)
+		log.Errorf("❌ node %x not added to graph", txn.Id)
+		c.unexecuted.remove(txn)
+	} else {
+		if err = c.graphStore.Save(c.graph, id); err != nil {
+			log.Errorf("❌ failed to persist node %x: %s", txn.Id, err)
+		}
+		c.unexecuted.add(txn)
+		c.publishAdded(txn)
 	}
 
-	// write transaction to the database
-	go set(txn)
-
 	// debug
 	/*
 		getTxnBytes, err := database.Get([]byte(DatabaseNamespace), txn.Id)
@@ -90,12 +105,12 @@ func AddBlock(txn *Transaction) {
 		proto.Unmarshal(getTxnBytes, txnUnmarsh)
 		fmt.Printf("Got transaction: ", txnUnmarsh)
 
-		nodeSize := len(g.Nodes())
+		nodeSize := len(c.graph.Nodes())
 		fmt.Println("Node count: ", nodeSize)
 		if nodeSize%100 == 0 {
 			fmt.Println("Ordering started...")
 
-			ordered, err := g.Order()
+			ordered, err := c.graph.Order()
 			if err != nil {
 				fmt.Errorf("❌ failed to order nodes after adding node %s: %s", txn.Id, err)
 			}
@@ -106,47 +121,46 @@ func AddBlock(txn *Transaction) {
 		}
 	*/
 
-	fmt.Println("Node count: ", len(g.Nodes()))
+	fmt.Println("Node count: ", len(c.graph.Nodes()))
 
-}
-
-func createContractHandler(id peer.ID, txn *Transaction) {
-	contractID, gas, err := eng.CreateWasmContract(txn.Data)
-	if err != nil {
-		fmt.Printf("An error has occured: %s\n", err.Error())
+	// add the transactions that were waiting on this one
+	for _, child := range c.orphans.take(id) {
+		c.addBlock(child.txn, child.from)
 	}
-	fmt.Printf("Contract created, ID: %s, Gas: %d\n", hex.EncodeToString(contractID[:]), gas)
-
-	AddBlock(txn)
 }
 
-func transactionHandler(id peer.ID, txn *Transaction) {
-	var contractID [32]byte
-	copy(contractID[:], txn.To[:32])
-
-	fmt.Printf("Calling Contract ID: %s\n", hex.EncodeToString(contractID[:]))
-
-	result, gas, err := eng.Call(contractID, string(txn.Data))
-	if err != nil {
-		fmt.Printf("An error has occured: %s\n", err.Error())
+// expireOrphans drops the transactions that waited too long for their
+// parents. The caller must hold mu.
+func (c *Chain) expireOrphans() {
+	for _, txn := range c.orphans.expire() {
+		log.Infof("dropping node %x waiting for its parents", txn.Id)
+		c.unexecuted.remove(txn)
 	}
-	fmt.Printf("result: %s, gas: %d\n", result, gas)
-	AddBlock(txn)
 }
 
-func set(txn *Transaction) {
+func (c *Chain) set(txn *Transaction) {
 	// add to the database
 	txnBytes, err := proto.Marshal(txn)
 	if err != nil {
-		fmt.Errorf("❌ failed to add node %s to db: %s", txn.Id, err)
+		log.Errorf("❌ failed to add node %x to db: %s", txn.Id, err)
+		return
+	}
+	err = c.db.Set([]byte(DatabaseNamespace), txn.Id, txnBytes)
+	if err != nil {
+		log.Errorf("❌ failed to add node %x to db: %s", txn.Id, err)
+		return
 	}
-	go Database.Set([]byte(DatabaseNamespace), txn.Id, txnBytes)
 	fmt.Println("Inserted key into db: ", hex.EncodeToString(txn.Id))
 }
 
-func PubsubHandler(ctx context.Context, sub *pubsub.Subscription) {
+// handleRequests adds the transactions of the requests received on the
+// subscription to the mempool, until ctx is done
+func (c *Chain) handleRequests(ctx context.Context, sub *pubsub.Subscription) {
 	for {
 		msg, err := sub.Next(ctx)
+		if ctx.Err() != nil {
+			return
+		}
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			continue
@@ -159,11 +173,21 @@ func PubsubHandler(ctx context.Context, sub *pubsub.Subscription) {
 			continue
 		}
 
+		// transactions wait in the mempool for DAG insertion, and are executed
+		// once they are confirmed in the DAG order
 		switch req.Type {
-		case Request_SEND_TRANSACTION:
-			transactionHandler(msg.GetFrom(), req.Transaction)
-		case Request_CREATE_CONTRACT:
-			createContractHandler(msg.GetFrom(), req.Transaction)
+		case Request_SEND_TRANSACTION, Request_CREATE_CONTRACT:
+			err = c.addPending(req.Transaction, msg.ReceivedFrom)
+			if err != nil {
+				log.Debugf("rejected transaction %x: %s", req.Transaction.GetId(), err)
+			}
+		case Request_BATCH:
+			for _, txn := range req.Transactions {
+				err = c.addPending(txn, msg.ReceivedFrom)
+				if err != nil {
+					log.Debugf("rejected transaction %x: %s", txn.GetId(), err)
+				}
+			}
 		}
 	}
 }
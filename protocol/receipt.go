@@ -0,0 +1,73 @@
+package protocol
+
+import (
+	"encoding/binary"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	receiptPrefix = []byte("receipt/")
+	orderPrefix   = []byte("order/")
+)
+
+// orderKey returns the key of the id of the transaction executed at the order
+// index
+func orderKey(index uint64) []byte {
+	key := make([]byte, len(orderPrefix)+8)
+	copy(key, orderPrefix)
+	binary.BigEndian.PutUint64(key[len(orderPrefix):], index)
+	return key
+}
+
+func receiptKey(id []byte) []byte {
+	key := make([]byte, 0, len(receiptPrefix)+len(id))
+	key = append(key, receiptPrefix...)
+	return append(key, id...)
+}
+
+// storeReceipt stores the receipt of an executed transaction, and indexes the
+// transaction by its execution sequence number
+func (c *Chain) storeReceipt(receipt *Receipt) error {
+	receiptBytes, err := proto.Marshal(receipt)
+	if err != nil {
+		return err
+	}
+
+	err = c.db.Set([]byte(ExecutorNamespace), receiptKey(receipt.TransactionId), receiptBytes)
+	if err != nil {
+		return err
+	}
+	return c.db.Set([]byte(ExecutorNamespace), orderKey(receipt.Index), receipt.TransactionId)
+}
+
+// getReceipt returns the receipt of a transaction, or nil if it has not been
+// executed
+func (c *Chain) getReceipt(id []byte) (*Receipt, error) {
+	ok, err := c.db.Has([]byte(ExecutorNamespace), receiptKey(id))
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	receiptBytes, err := c.db.Get([]byte(ExecutorNamespace), receiptKey(id))
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &Receipt{}
+	err = proto.Unmarshal(receiptBytes, receipt)
+	if err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// getExecutedID returns the id of the transaction with the execution sequence
+// number, or nil if there is none
+func (c *Chain) getExecutedID(index uint64) ([]byte, error) {
+	ok, err := c.db.Has([]byte(ExecutorNamespace), orderKey(index))
+	if err != nil || !ok {
+		return nil, err
+	}
+	return c.db.Get([]byte(ExecutorNamespace), orderKey(index))
+}
@@ -1,65 +1,56 @@
 package protocol
 
 import (
+	"bytes"
 	"context"
-	"crypto/sha256"
 	hex "encoding/hex"
 	"errors"
 	"fmt"
 	"log"
-	"net"
-	reflect "reflect"
-	"strconv"
 	"time"
 
-	pubsub "github.com/libp2p/go-libp2p-pubsub"
-	grpc "google.golang.org/grpc"
+	"github.com/sporeframework/spore/contract"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
-
-	"github.com/ethereum/go-ethereum/crypto"
 )
 
-var ps *pubsub.PubSub
-var pubsubTopic string
-
-// server is used to implement helloworld.GreeterServer.
+// server implements the Spore RPC interface of a chain
 type server struct {
 	UnimplementedSporeServer
+	chain *Chain
 }
 
-func StartRPCServer(topic string, pubsub *pubsub.PubSub, p *int) {
-	ps = pubsub
-	pubsubTopic = topic
-	port := ":" + strconv.Itoa(*p)
-	fmt.Println("RPC interface listenting on tcp", port)
-	lis, err := net.Listen("tcp", port)
-	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
-	}
-	s := grpc.NewServer()
-	RegisterSporeServer(s, &server{})
-	if err := s.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
-	}
+// NewServer returns the RPC interface of a chain, to register on a gRPC server
+// with RegisterSporeServer. Transactions sent through it are published on the
+// network of the chain, so it must be started.
+func NewServer(c *Chain) SporeServer {
+	return &server{chain: c}
 }
 
 func (s *server) CreateContract(ctx context.Context, in *Transaction) (*TransactionResponse, error) {
 	log.Printf("Received: %v", hex.EncodeToString(in.GetData()))
-	if checkSignature(in) == false {
-		return nil, errors.New("Could not validate signature")
+	if err := s.chain.checkTransaction(in, true); err != nil {
+		return nil, err
+	}
+	err := s.chain.acceptTransaction(in)
+	if err == errDuplicateTransaction {
+		// published already, by a call retried after its response was lost
+		return &TransactionResponse{TransactionId: in.GetId()}, nil
+	}
+	if err != nil {
+		return nil, err
 	}
-	setMetadata(in)
 	req := &Request{
 		Type:        Request_CREATE_CONTRACT,
 		Transaction: in,
 	}
-	msgBytes, err := proto.Marshal(req)
-	if err != nil {
+	if err = s.chain.publishRequest(req); err != nil {
+		s.chain.releaseNonce(in)
 		return nil, err
 	}
-	err = ps.Publish(pubsubTopic, msgBytes)
-	dataHash := sha256.Sum256(in.Data)
-	return &TransactionResponse{TransactionId: dataHash[:]}, nil
+
+	return &TransactionResponse{TransactionId: in.GetId()}, nil
 }
 
 func (s *server) GetTransaction(ctx context.Context, in *TransactionId) (*Transaction, error) {
@@ -67,67 +58,245 @@ func (s *server) GetTransaction(ctx context.Context, in *TransactionId) (*Transa
 
 	log.Println("Querying database with txn id: ", hex.EncodeToString(in.GetTransactionId()))
 	// we don't have to broadcast this call to the network, it is a local query
-	txnBytes, err := Database.Get([]byte(DatabaseNamespace), in.GetTransactionId())
-	if err != nil {
-		return nil, err
+	return s.chain.getTransaction(in.GetTransactionId())
+}
+
+// GetPending implements Spore.GetPending
+func (s *server) GetPending(ctx context.Context, in *PendingRequest) (*PendingResponse, error) {
+	return &PendingResponse{
+		Transactions: s.chain.pool.Pending(in.GetFrom(), int(in.GetLimit())),
+		Size:         uint32(s.chain.pool.Len()),
+	}, nil
+}
+
+// GetPendingTransaction implements Spore.GetPendingTransaction
+func (s *server) GetPendingTransaction(ctx context.Context, in *TransactionId) (*Transaction, error) {
+	txn := s.chain.pool.Get(in.GetTransactionId())
+	if txn == nil {
+		return nil, fmt.Errorf("transaction %x is not pending", in.GetTransactionId())
 	}
+	return txn, nil
+}
 
-	txn := &Transaction{}
-	err = proto.Unmarshal(txnBytes, txn)
+// GetReceipt implements Spore.GetReceipt
+func (s *server) GetReceipt(ctx context.Context, in *TransactionId) (*Receipt, error) {
+	receipt, err := s.chain.getReceipt(in.GetTransactionId())
 	if err != nil {
 		return nil, err
 	}
-	return txn, nil
+	if receipt == nil {
+		return nil, status.Errorf(codes.NotFound, "transaction %x has not been executed", in.GetTransactionId())
+	}
+	return receipt, nil
 }
 
 // Send implements Spore.Send
 func (s *server) Send(ctx context.Context, in *Transaction) (*TransactionResponse, error) {
 	log.Printf("Received: %v", hex.EncodeToString(in.GetData()))
-	if !checkSignature(in) {
-		return nil, errors.New("Could not validate signature")
+	if err := s.chain.checkTransaction(in, false); err != nil {
+		return nil, err
+	}
+	err := s.chain.acceptTransaction(in)
+	if err == errDuplicateTransaction {
+		// published already, by a call retried after its response was lost
+		return &TransactionResponse{TransactionId: in.GetId()}, nil
+	}
+	if err != nil {
+		return nil, err
 	}
-	setMetadata(in)
 	req := &Request{
 		Type:        Request_SEND_TRANSACTION,
 		Transaction: in,
 	}
-	msgBytes, err := proto.Marshal(req)
-	if err != nil {
+	if err = s.chain.publishRequest(req); err != nil {
+		s.chain.releaseNonce(in)
 		return nil, err
 	}
-	err = ps.Publish(pubsubTopic, msgBytes)
 
 	return &TransactionResponse{TransactionId: in.GetId()}, nil
 }
 
-func setMetadata(in *Transaction) error {
+// GetTips implements Spore.GetTips
+func (s *server) GetTips(ctx context.Context, in *TipsRequest) (*TipsResponse, error) {
+	s.chain.mu.Lock()
+	tips, err := s.chain.graph.Tips()
+	s.chain.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &TipsResponse{Tips: make([][]byte, len(tips))}
+	for i, tip := range tips {
+		resp.Tips[i] = []byte(tip)
+	}
+	return resp, nil
+}
+
+// GetAccountNonce implements Spore.GetAccountNonce
+func (s *server) GetAccountNonce(ctx context.Context, in *Account) (*AccountNonce, error) {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+
+	nonce, err := s.chain.getAccountNonce(in.GetAddress())
+	if err != nil {
+		return nil, err
+	}
+	pending, err := s.chain.getPendingNonce(in.GetAddress())
+	if err != nil {
+		return nil, err
+	}
+	return &AccountNonce{Nonce: nonce, PendingNonce: pending}, nil
+}
 
-	// set the id to the transaction's hash
-	txnBytes, _ := proto.Marshal(in)
-	dataHash := sha256.Sum256(txnBytes)
-	in.Id = dataHash[:]
+// GetBalance implements Spore.GetBalance
+func (s *server) GetBalance(ctx context.Context, in *Account) (*Balance, error) {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
 
-	now := time.Now().Unix()
-	in.Created = now
+	balance, err := s.chain.getBalance(in.GetAddress())
+	if err != nil {
+		return nil, err
+	}
+	return &Balance{Balance: balance}, nil
+}
+
+// Query implements Spore.Query
+func (s *server) Query(ctx context.Context, in *QueryRequest) (*QueryResponse, error) {
+	if len(in.Contract) != 32 {
+		return nil, errors.New("contract id must be 32 bytes")
+	}
+	var contractID [32]byte
+	copy(contractID[:], in.Contract)
+
+	gas := in.Gas
+	limit := s.chain.conf.Contracts.QueryGasLimit
+	if gas <= 0 || gas > limit {
+		gas = limit
+	}
+
+	// queries run at the coloring tip of the DAG
+	env := &contract.Environment{Caller: in.From}
+	s.chain.mu.Lock()
+	if tip := s.chain.graph.ColoringTip(); tip != "" {
+		blueScore, _ := s.chain.graph.BlueScore(tip)
+		height, _ := s.chain.graph.Height(tip)
+		env.BlueScore = int64(blueScore)
+		env.Height = int64(height)
+	}
+	s.chain.mu.Unlock()
+
+	// the engine serializes the query with execution, so mu is not needed
+	result, gasUsed, err := s.chain.engine.Query(contractID, env, gas, in.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &QueryResponse{Result: result, GasUsed: gasUsed}, nil
+}
+
+// checkTransaction runs the checks of a transaction received over RPC that do
+// not depend on the state of the node, so that they may run in parallel
+func (c *Chain) checkTransaction(txn *Transaction, create bool) error {
+	if err := verifySignature(txn); err != nil {
+		return fmt.Errorf("Could not validate signature: %s", err)
+	}
+	if create && len(txn.To) > 0 {
+		return errors.New("contract creation must not have a recipient")
+	}
+	if !create && len(txn.To) == 0 {
+		return errors.New("transaction has no recipient")
+	}
+	return c.checkSize(txn)
+}
+
+// acceptTransaction runs the checks of a transaction received over RPC that
+// depend on the state of the node, sets its metadata and reserves its nonce.
+// The nonce must be released with releaseNonce if the transaction is not
+// published. It returns errDuplicateTransaction for a transaction known to the
+// node already, a send retried after its response was lost for instance,
+// which must not be published again.
+func (c *Chain) acceptTransaction(txn *Transaction) error {
+	if err := c.checkFee(txn); err != nil {
+		return err
+	}
+	if err := c.checkParents(txn); err != nil {
+		return err
+	}
+	if err := setMetadata(txn); err != nil {
+		return err
+	}
+	return c.checkNonce(txn)
+}
+
+// publishRequest broadcasts a request to the network, this node included
+func (c *Chain) publishRequest(req *Request) error {
+	if c.ps == nil {
+		return errors.New("node is not connected to the network")
+	}
+	msgBytes, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return c.ps.Publish(PubsubTopic, msgBytes)
+}
+
+// checkFee verifies at ingress that a transaction pays at least the minimum
+// gas price of the node and that its sender can currently afford it
+func (c *Chain) checkFee(txn *Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.checkFeeLocked(txn)
+}
+
+// checkFeeLocked is checkFee for callers holding mu
+func (c *Chain) checkFeeLocked(txn *Transaction) error {
+	if txn.Gas <= 0 {
+		return errors.New("transaction gas limit must be positive")
+	}
+	if minGasPrice := c.conf.RPC.MinGasPrice; txn.GasPrice < minGasPrice {
+		return fmt.Errorf("gas price %d is below the minimum of %d", txn.GasPrice, minGasPrice)
+	}
+	return c.checkBalance(txn)
+}
+
+// checkParents verifies that the signed parents of a transaction are known to
+// this node. Only the first transaction of an empty DAG may omit its parents.
+func (c *Chain) checkParents(txn *Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(txn.Parents) == 0 {
+		return c.checkRoot(txn)
+	}
+
+	for _, p := range txn.Parents {
+		if exists, _ := c.graph.NodeExists(string(p)); !exists {
+			return fmt.Errorf("unknown parent transaction %x", p)
+		}
+	}
+	return nil
+}
+
+// setMetadata sets the id of a transaction accepted on ingress to its hash,
+// and the time it was received
+func setMetadata(in *Transaction) error {
+	in.Id = TransactionHash(in)
+	in.Created = time.Now().Unix()
 	return nil
 }
 
-func checkSignature(txn *Transaction) bool {
-	sig := make([]byte, len(txn.Signature))
-	copy(sig, txn.Signature)
-	txn.Signature = nil
-	txnBytes, _ := proto.Marshal(txn)
-	pSum := sha256.Sum256(txnBytes)
-	//fmt.Printf("pSum: %s\n", hex.EncodeToString(pSum[:]))
-
-	// reset sig
-	txn.Signature = sig
-	publicKey, _ := crypto.SigToPub(pSum[:], txn.Signature)
-	//publicKey, _ := crypto.Ecrecover(pSum[:], txn.Signature)
-	address := crypto.PubkeyToAddress(*publicKey)
-	//fmt.Printf("sig: %s\n", hex.EncodeToString(sig))
-	//fmt.Printf("address: %s\n", hex.EncodeToString(address.Bytes()))
-	//fmt.Printf("public tx.from: %s\n", hex.EncodeToString(txn.GetFrom()))
-	return reflect.DeepEqual(address.Bytes(), txn.GetFrom())
+// verifyTransaction checks a transaction received from another node: its
+// signature, and that its id is its hash.
+func verifyTransaction(txn *Transaction) error {
+	if txn == nil {
+		return errors.New("missing transaction")
+	}
+	if err := verifySignature(txn); err != nil {
+		return fmt.Errorf("Could not validate signature: %s", err)
+	}
 
+	hash := TransactionHash(txn)
+	if !bytes.Equal(hash, txn.Id) {
+		return fmt.Errorf("transaction id %x does not match its hash %x", txn.Id, hash)
+	}
+	return nil
 }
@@ -0,0 +1,162 @@
+package protocol
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SignatureVerifier verifies the signature of a transaction and returns the
+// address of the signer
+type SignatureVerifier func(txn *Transaction) ([]byte, error)
+
+var (
+	verifiersMu sync.RWMutex
+	verifiers   = map[SignatureScheme]SignatureVerifier{
+		SignatureScheme_SECP256K1: verifySecp256k1,
+		SignatureScheme_EIP191:    verifyEIP191,
+		SignatureScheme_EIP712:    verifyEIP712,
+		SignatureScheme_ED25519:   verifyEd25519,
+	}
+
+	// eip712DomainSeparator is the hash of the EIP-712 domain of spore
+	// transactions: EIP712Domain(string name,string version) with name "Spore"
+	// and version "1"
+	eip712DomainSeparator = crypto.Keccak256(
+		crypto.Keccak256([]byte("EIP712Domain(string name,string version)")),
+		crypto.Keccak256([]byte("Spore")),
+		crypto.Keccak256([]byte("1")),
+	)
+
+	// eip712TransactionType is the hash of the EIP-712 type of a transaction
+	eip712TransactionType = crypto.Keccak256([]byte("Transaction(bytes to,bytes from,bytes data,int64 gas,int64 gasPrice,int32 nonce,int64 value,bool contract,bytes32[] parents)"))
+)
+
+// RegisterVerifier sets the verifier of a signature scheme, replacing the
+// built-in one if there is any
+func RegisterVerifier(scheme SignatureScheme, verifier SignatureVerifier) {
+	verifiersMu.Lock()
+	defer verifiersMu.Unlock()
+	verifiers[scheme] = verifier
+}
+
+// verifySignature checks that the transaction is signed by its sender with its
+// signature scheme
+func verifySignature(txn *Transaction) error {
+	verifiersMu.RLock()
+	verifier, ok := verifiers[txn.Scheme]
+	verifiersMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown signature scheme %d", txn.Scheme)
+	}
+
+	address, err := verifier(txn)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(address, txn.GetFrom()) {
+		return fmt.Errorf("transaction is signed by %x, not by its sender %x", address, txn.GetFrom())
+	}
+	return nil
+}
+
+// recoverAddress returns the Ethereum address that signed the hash. V may be
+// 0/1 or 27/28, as returned by most Ethereum wallets.
+func recoverAddress(hash, sig []byte) ([]byte, error) {
+	if len(sig) != crypto.SignatureLength {
+		return nil, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig = append([]byte{}, sig...)
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	publicKey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.PubkeyToAddress(*publicKey).Bytes(), nil
+}
+
+func verifySecp256k1(txn *Transaction) ([]byte, error) {
+	return recoverAddress(TransactionHash(txn), txn.Signature)
+}
+
+// verifyEIP191 verifies an Ethereum personal_sign signature of the transaction
+// hash
+func verifyEIP191(txn *Transaction) ([]byte, error) {
+	return recoverAddress(accounts.TextHash(TransactionHash(txn)), txn.Signature)
+}
+
+// verifyEIP712 verifies an EIP-712 typed data signature of the transaction
+func verifyEIP712(txn *Transaction) ([]byte, error) {
+	hash, err := EIP712Hash(txn)
+	if err != nil {
+		return nil, err
+	}
+	return recoverAddress(hash, txn.Signature)
+}
+
+// verifyEd25519 verifies an ed25519 signature of the transaction hash by the
+// public key of the transaction. The address of an ed25519 key is the last 20
+// bytes of the keccak256 hash of the key.
+func verifyEd25519(txn *Transaction) ([]byte, error) {
+	if len(txn.PublicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key length %d", len(txn.PublicKey))
+	}
+	if !ed25519.Verify(txn.PublicKey, TransactionHash(txn), txn.Signature) {
+		return nil, errors.New("invalid ed25519 signature")
+	}
+	return Ed25519Address(txn.PublicKey), nil
+}
+
+// Ed25519Address returns the address of an ed25519 public key
+func Ed25519Address(publicKey ed25519.PublicKey) []byte {
+	return crypto.Keccak256(publicKey)[12:]
+}
+
+// EIP712Hash returns the EIP-712 digest of a transaction, which is what wallets
+// sign with eth_signTypedData_v4 for the domain {name: "Spore", version: "1"}
+// and the type
+//
+//	Transaction(bytes to,bytes from,bytes data,int64 gas,int64 gasPrice,int32 nonce,int64 value,bool contract,bytes32[] parents)
+func EIP712Hash(txn *Transaction) ([]byte, error) {
+	parents := make([]byte, 0, len(txn.Parents)*32)
+	for _, p := range txn.Parents {
+		if len(p) != 32 {
+			return nil, fmt.Errorf("invalid parent id of %d bytes", len(p))
+		}
+		parents = append(parents, p...)
+	}
+
+	contract := int64(0)
+	if txn.Contract {
+		contract = 1
+	}
+
+	structHash := crypto.Keccak256(
+		eip712TransactionType,
+		crypto.Keccak256(txn.To),
+		crypto.Keccak256(txn.From),
+		crypto.Keccak256(txn.Data),
+		eip712Int(txn.Gas),
+		eip712Int(txn.GasPrice),
+		eip712Int(int64(txn.Nonce)),
+		eip712Int(txn.Value),
+		eip712Int(contract),
+		crypto.Keccak256(parents),
+	)
+	return crypto.Keccak256([]byte{0x19, 0x01}, eip712DomainSeparator, structHash), nil
+}
+
+// eip712Int encodes a signed integer as a 32-byte two's complement word
+func eip712Int(v int64) []byte {
+	return math.U256Bytes(big.NewInt(v))
+}
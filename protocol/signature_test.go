@@ -0,0 +1,91 @@
+package protocol
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func signatureTxn() *Transaction {
+	return &Transaction{To: make([]byte, 32), Data: []byte("increment"), Gas: 1000, Parents: [][]byte{make([]byte, 32)}}
+}
+
+func TestVerifySignature_Secp256k1(t *testing.T) {
+	prv, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(prv.PublicKey).Bytes()
+
+	hashes := map[SignatureScheme]func(*Transaction) []byte{
+		SignatureScheme_SECP256K1: TransactionHash,
+		SignatureScheme_EIP191: func(txn *Transaction) []byte {
+			return accounts.TextHash(TransactionHash(txn))
+		},
+		SignatureScheme_EIP712: func(txn *Transaction) []byte {
+			hash, _ := EIP712Hash(txn)
+			return hash
+		},
+	}
+	for scheme, hash := range hashes {
+		txn := signatureTxn()
+		txn.From = address
+		txn.Scheme = scheme
+
+		sig, err := crypto.Sign(hash(txn), prv)
+		if err != nil {
+			t.Fatalf("%s: failed to sign transaction: %s", scheme, err)
+		}
+		txn.Signature = sig
+		if err := verifySignature(txn); err != nil {
+			t.Errorf("%s: expected valid signature, got %s", scheme, err)
+		}
+
+		// wallets return V as 27 or 28
+		txn.Signature[crypto.RecoveryIDOffset] += 27
+		if err := verifySignature(txn); err != nil {
+			t.Errorf("%s: expected valid signature with V of %d, got %s", scheme, txn.Signature[crypto.RecoveryIDOffset], err)
+		}
+
+		txn.From = make([]byte, AddressLength)
+		if err := verifySignature(txn); err == nil {
+			t.Errorf("%s: expected signature of another sender to be rejected", scheme)
+		}
+	}
+}
+
+func TestVerifySignature_Ed25519(t *testing.T) {
+	pub, prv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	txn := signatureTxn()
+	txn.Scheme = SignatureScheme_ED25519
+	txn.PublicKey = pub
+	txn.From = Ed25519Address(pub)
+	txn.Signature = ed25519.Sign(prv, TransactionHash(txn))
+	if err := verifySignature(txn); err != nil {
+		t.Fatalf("expected valid signature, got %s", err)
+	}
+
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	txn.PublicKey = other
+	if err := verifySignature(txn); err == nil {
+		t.Error("expected signature of another key to be rejected")
+	}
+
+	txn.PublicKey = pub
+	txn.Scheme = SignatureScheme_SECP256K1
+	if err := verifySignature(txn); err == nil {
+		t.Error("expected signature of another scheme to be rejected")
+	}
+}
+
+func TestVerifySignature_UnknownScheme(t *testing.T) {
+	txn := signatureTxn()
+	txn.Scheme = SignatureScheme(99)
+	if err := verifySignature(txn); err == nil {
+		t.Error("expected unknown signature scheme to be rejected")
+	}
+}
@@ -22,8 +22,39 @@ type SporeClient interface {
 	Send(ctx context.Context, in *Transaction, opts ...grpc.CallOption) (*TransactionResponse, error)
 	// Sends a Contract
 	CreateContract(ctx context.Context, in *Transaction, opts ...grpc.CallOption) (*TransactionResponse, error)
+	// Sends many transactions and contracts, returning a result per transaction
+	// in the same order. A failed transaction does not abort the batch.
+	SendBatch(ctx context.Context, in *TransactionBatch, opts ...grpc.CallOption) (*BatchResponse, error)
+	// Streams transactions and contracts to send, returning a result per
+	// transaction in the order they were streamed
+	SendStream(ctx context.Context, opts ...grpc.CallOption) (Spore_SendStreamClient, error)
 	// Get transaction by transaction id
 	GetTransaction(ctx context.Context, in *TransactionId, opts ...grpc.CallOption) (*Transaction, error)
+	// Get the ids of the current tips of the DAG, to be used as parents
+	GetTips(ctx context.Context, in *TipsRequest, opts ...grpc.CallOption) (*TipsResponse, error)
+	// Get a range of the DAG order, the order in which transactions execute
+	GetOrder(ctx context.Context, in *OrderRequest, opts ...grpc.CallOption) (*OrderResponse, error)
+	// Get the position of a transaction in the DAG
+	GetDagNode(ctx context.Context, in *TransactionId, opts ...grpc.CallOption) (*DagNode, error)
+	// Get the status of the node
+	GetStatus(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*Status, error)
+	// Get the peers the node is connected to
+	GetPeers(ctx context.Context, in *PeersRequest, opts ...grpc.CallOption) (*PeersResponse, error)
+	// Get the nonce the next transaction of an account must use
+	GetAccountNonce(ctx context.Context, in *Account, opts ...grpc.CallOption) (*AccountNonce, error)
+	// Get the balance of an account
+	GetBalance(ctx context.Context, in *Account, opts ...grpc.CallOption) (*Balance, error)
+	// Get the receipt of an executed transaction by transaction id
+	GetReceipt(ctx context.Context, in *TransactionId, opts ...grpc.CallOption) (*Receipt, error)
+	// Get the transactions waiting in the mempool, in priority order
+	GetPending(ctx context.Context, in *PendingRequest, opts ...grpc.CallOption) (*PendingResponse, error)
+	// Get a transaction waiting in the mempool by transaction id
+	GetPendingTransaction(ctx context.Context, in *TransactionId, opts ...grpc.CallOption) (*Transaction, error)
+	// Call a contract function against current state without sending a
+	// transaction. Nothing is persisted or published.
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error)
+	// Stream DAG, execution and contract events matching a filter
+	SubscribeEvents(ctx context.Context, in *EventFilter, opts ...grpc.CallOption) (Spore_SubscribeEventsClient, error)
 }
 
 type sporeClient struct {
@@ -52,6 +83,49 @@ func (c *sporeClient) CreateContract(ctx context.Context, in *Transaction, opts
 	return out, nil
 }
 
+func (c *sporeClient) SendBatch(ctx context.Context, in *TransactionBatch, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, "/main.Spore/SendBatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sporeClient) SendStream(ctx context.Context, opts ...grpc.CallOption) (Spore_SendStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Spore_ServiceDesc.Streams[0], "/main.Spore/SendStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sporeSendStreamClient{stream}
+	return x, nil
+}
+
+type Spore_SendStreamClient interface {
+	Send(*Transaction) error
+	CloseAndRecv() (*BatchResponse, error)
+	grpc.ClientStream
+}
+
+type sporeSendStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *sporeSendStreamClient) Send(m *Transaction) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *sporeSendStreamClient) CloseAndRecv() (*BatchResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(BatchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *sporeClient) GetTransaction(ctx context.Context, in *TransactionId, opts ...grpc.CallOption) (*Transaction, error) {
 	out := new(Transaction)
 	err := c.cc.Invoke(ctx, "/main.Spore/GetTransaction", in, out, opts...)
@@ -61,6 +135,137 @@ func (c *sporeClient) GetTransaction(ctx context.Context, in *TransactionId, opt
 	return out, nil
 }
 
+func (c *sporeClient) GetTips(ctx context.Context, in *TipsRequest, opts ...grpc.CallOption) (*TipsResponse, error) {
+	out := new(TipsResponse)
+	err := c.cc.Invoke(ctx, "/main.Spore/GetTips", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sporeClient) GetOrder(ctx context.Context, in *OrderRequest, opts ...grpc.CallOption) (*OrderResponse, error) {
+	out := new(OrderResponse)
+	err := c.cc.Invoke(ctx, "/main.Spore/GetOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sporeClient) GetDagNode(ctx context.Context, in *TransactionId, opts ...grpc.CallOption) (*DagNode, error) {
+	out := new(DagNode)
+	err := c.cc.Invoke(ctx, "/main.Spore/GetDagNode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sporeClient) GetStatus(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*Status, error) {
+	out := new(Status)
+	err := c.cc.Invoke(ctx, "/main.Spore/GetStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sporeClient) GetPeers(ctx context.Context, in *PeersRequest, opts ...grpc.CallOption) (*PeersResponse, error) {
+	out := new(PeersResponse)
+	err := c.cc.Invoke(ctx, "/main.Spore/GetPeers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sporeClient) GetAccountNonce(ctx context.Context, in *Account, opts ...grpc.CallOption) (*AccountNonce, error) {
+	out := new(AccountNonce)
+	err := c.cc.Invoke(ctx, "/main.Spore/GetAccountNonce", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sporeClient) GetBalance(ctx context.Context, in *Account, opts ...grpc.CallOption) (*Balance, error) {
+	out := new(Balance)
+	err := c.cc.Invoke(ctx, "/main.Spore/GetBalance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sporeClient) GetReceipt(ctx context.Context, in *TransactionId, opts ...grpc.CallOption) (*Receipt, error) {
+	out := new(Receipt)
+	err := c.cc.Invoke(ctx, "/main.Spore/GetReceipt", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sporeClient) GetPending(ctx context.Context, in *PendingRequest, opts ...grpc.CallOption) (*PendingResponse, error) {
+	out := new(PendingResponse)
+	err := c.cc.Invoke(ctx, "/main.Spore/GetPending", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sporeClient) GetPendingTransaction(ctx context.Context, in *TransactionId, opts ...grpc.CallOption) (*Transaction, error) {
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, "/main.Spore/GetPendingTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sporeClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error) {
+	out := new(QueryResponse)
+	err := c.cc.Invoke(ctx, "/main.Spore/Query", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sporeClient) SubscribeEvents(ctx context.Context, in *EventFilter, opts ...grpc.CallOption) (Spore_SubscribeEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Spore_ServiceDesc.Streams[1], "/main.Spore/SubscribeEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sporeSubscribeEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Spore_SubscribeEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type sporeSubscribeEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *sporeSubscribeEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // SporeServer is the server API for Spore service.
 // All implementations must embed UnimplementedSporeServer
 // for forward compatibility
@@ -69,8 +274,39 @@ type SporeServer interface {
 	Send(context.Context, *Transaction) (*TransactionResponse, error)
 	// Sends a Contract
 	CreateContract(context.Context, *Transaction) (*TransactionResponse, error)
+	// Sends many transactions and contracts, returning a result per transaction
+	// in the same order. A failed transaction does not abort the batch.
+	SendBatch(context.Context, *TransactionBatch) (*BatchResponse, error)
+	// Streams transactions and contracts to send, returning a result per
+	// transaction in the order they were streamed
+	SendStream(Spore_SendStreamServer) error
 	// Get transaction by transaction id
 	GetTransaction(context.Context, *TransactionId) (*Transaction, error)
+	// Get the ids of the current tips of the DAG, to be used as parents
+	GetTips(context.Context, *TipsRequest) (*TipsResponse, error)
+	// Get a range of the DAG order, the order in which transactions execute
+	GetOrder(context.Context, *OrderRequest) (*OrderResponse, error)
+	// Get the position of a transaction in the DAG
+	GetDagNode(context.Context, *TransactionId) (*DagNode, error)
+	// Get the status of the node
+	GetStatus(context.Context, *StatusRequest) (*Status, error)
+	// Get the peers the node is connected to
+	GetPeers(context.Context, *PeersRequest) (*PeersResponse, error)
+	// Get the nonce the next transaction of an account must use
+	GetAccountNonce(context.Context, *Account) (*AccountNonce, error)
+	// Get the balance of an account
+	GetBalance(context.Context, *Account) (*Balance, error)
+	// Get the receipt of an executed transaction by transaction id
+	GetReceipt(context.Context, *TransactionId) (*Receipt, error)
+	// Get the transactions waiting in the mempool, in priority order
+	GetPending(context.Context, *PendingRequest) (*PendingResponse, error)
+	// Get a transaction waiting in the mempool by transaction id
+	GetPendingTransaction(context.Context, *TransactionId) (*Transaction, error)
+	// Call a contract function against current state without sending a
+	// transaction. Nothing is persisted or published.
+	Query(context.Context, *QueryRequest) (*QueryResponse, error)
+	// Stream DAG, execution and contract events matching a filter
+	SubscribeEvents(*EventFilter, Spore_SubscribeEventsServer) error
 	mustEmbedUnimplementedSporeServer()
 }
 
@@ -84,9 +320,51 @@ func (UnimplementedSporeServer) Send(context.Context, *Transaction) (*Transactio
 func (UnimplementedSporeServer) CreateContract(context.Context, *Transaction) (*TransactionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateContract not implemented")
 }
+func (UnimplementedSporeServer) SendBatch(context.Context, *TransactionBatch) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendBatch not implemented")
+}
+func (UnimplementedSporeServer) SendStream(Spore_SendStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method SendStream not implemented")
+}
 func (UnimplementedSporeServer) GetTransaction(context.Context, *TransactionId) (*Transaction, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetTransaction not implemented")
 }
+func (UnimplementedSporeServer) GetTips(context.Context, *TipsRequest) (*TipsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTips not implemented")
+}
+func (UnimplementedSporeServer) GetOrder(context.Context, *OrderRequest) (*OrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrder not implemented")
+}
+func (UnimplementedSporeServer) GetDagNode(context.Context, *TransactionId) (*DagNode, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDagNode not implemented")
+}
+func (UnimplementedSporeServer) GetStatus(context.Context, *StatusRequest) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedSporeServer) GetPeers(context.Context, *PeersRequest) (*PeersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPeers not implemented")
+}
+func (UnimplementedSporeServer) GetAccountNonce(context.Context, *Account) (*AccountNonce, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccountNonce not implemented")
+}
+func (UnimplementedSporeServer) GetBalance(context.Context, *Account) (*Balance, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (UnimplementedSporeServer) GetReceipt(context.Context, *TransactionId) (*Receipt, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReceipt not implemented")
+}
+func (UnimplementedSporeServer) GetPending(context.Context, *PendingRequest) (*PendingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPending not implemented")
+}
+func (UnimplementedSporeServer) GetPendingTransaction(context.Context, *TransactionId) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPendingTransaction not implemented")
+}
+func (UnimplementedSporeServer) Query(context.Context, *QueryRequest) (*QueryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedSporeServer) SubscribeEvents(*EventFilter, Spore_SubscribeEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeEvents not implemented")
+}
 func (UnimplementedSporeServer) mustEmbedUnimplementedSporeServer() {}
 
 // UnsafeSporeServer may be embedded to opt out of forward compatibility for this service.
@@ -136,6 +414,50 @@ func _Spore_CreateContract_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Spore_SendBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionBatch)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SporeServer).SendBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Spore/SendBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SporeServer).SendBatch(ctx, req.(*TransactionBatch))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Spore_SendStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SporeServer).SendStream(&sporeSendStreamServer{stream})
+}
+
+type Spore_SendStreamServer interface {
+	SendAndClose(*BatchResponse) error
+	Recv() (*Transaction, error)
+	grpc.ServerStream
+}
+
+type sporeSendStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *sporeSendStreamServer) SendAndClose(m *BatchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *sporeSendStreamServer) Recv() (*Transaction, error) {
+	m := new(Transaction)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func _Spore_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(TransactionId)
 	if err := dec(in); err != nil {
@@ -154,6 +476,225 @@ func _Spore_GetTransaction_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Spore_GetTips_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TipsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SporeServer).GetTips(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Spore/GetTips",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SporeServer).GetTips(ctx, req.(*TipsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Spore_GetOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SporeServer).GetOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Spore/GetOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SporeServer).GetOrder(ctx, req.(*OrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Spore_GetDagNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SporeServer).GetDagNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Spore/GetDagNode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SporeServer).GetDagNode(ctx, req.(*TransactionId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Spore_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SporeServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Spore/GetStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SporeServer).GetStatus(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Spore_GetPeers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PeersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SporeServer).GetPeers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Spore/GetPeers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SporeServer).GetPeers(ctx, req.(*PeersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Spore_GetAccountNonce_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Account)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SporeServer).GetAccountNonce(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Spore/GetAccountNonce",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SporeServer).GetAccountNonce(ctx, req.(*Account))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Spore_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Account)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SporeServer).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Spore/GetBalance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SporeServer).GetBalance(ctx, req.(*Account))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Spore_GetReceipt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SporeServer).GetReceipt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Spore/GetReceipt",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SporeServer).GetReceipt(ctx, req.(*TransactionId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Spore_GetPending_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PendingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SporeServer).GetPending(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Spore/GetPending",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SporeServer).GetPending(ctx, req.(*PendingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Spore_GetPendingTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SporeServer).GetPendingTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Spore/GetPendingTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SporeServer).GetPendingTransaction(ctx, req.(*TransactionId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Spore_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SporeServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Spore/Query",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SporeServer).Query(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Spore_SubscribeEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SporeServer).SubscribeEvents(m, &sporeSubscribeEventsServer{stream})
+}
+
+type Spore_SubscribeEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type sporeSubscribeEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *sporeSubscribeEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Spore_ServiceDesc is the grpc.ServiceDesc for Spore service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -169,11 +710,70 @@ var Spore_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CreateContract",
 			Handler:    _Spore_CreateContract_Handler,
 		},
+		{
+			MethodName: "SendBatch",
+			Handler:    _Spore_SendBatch_Handler,
+		},
 		{
 			MethodName: "GetTransaction",
 			Handler:    _Spore_GetTransaction_Handler,
 		},
+		{
+			MethodName: "GetTips",
+			Handler:    _Spore_GetTips_Handler,
+		},
+		{
+			MethodName: "GetOrder",
+			Handler:    _Spore_GetOrder_Handler,
+		},
+		{
+			MethodName: "GetDagNode",
+			Handler:    _Spore_GetDagNode_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _Spore_GetStatus_Handler,
+		},
+		{
+			MethodName: "GetPeers",
+			Handler:    _Spore_GetPeers_Handler,
+		},
+		{
+			MethodName: "GetAccountNonce",
+			Handler:    _Spore_GetAccountNonce_Handler,
+		},
+		{
+			MethodName: "GetBalance",
+			Handler:    _Spore_GetBalance_Handler,
+		},
+		{
+			MethodName: "GetReceipt",
+			Handler:    _Spore_GetReceipt_Handler,
+		},
+		{
+			MethodName: "GetPending",
+			Handler:    _Spore_GetPending_Handler,
+		},
+		{
+			MethodName: "GetPendingTransaction",
+			Handler:    _Spore_GetPendingTransaction_Handler,
+		},
+		{
+			MethodName: "Query",
+			Handler:    _Spore_Query_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SendStream",
+			Handler:       _Spore_SendStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "SubscribeEvents",
+			Handler:       _Spore_SubscribeEvents_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "spore.proto",
 }
@@ -0,0 +1,113 @@
+package protocol
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetOrder implements Spore.GetOrder
+func (s *server) GetOrder(ctx context.Context, in *OrderRequest) (*OrderResponse, error) {
+	s.chain.mu.Lock()
+	order, err := s.chain.graph.Order()
+	s.chain.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &OrderResponse{Size: uint64(len(order))}
+	if in.Start >= uint64(len(order)) {
+		return resp, nil
+	}
+	order = order[in.Start:]
+	if in.Limit > 0 && len(order) > int(in.Limit) {
+		order = order[:in.Limit]
+	}
+
+	resp.Transactions = make([][]byte, len(order))
+	for i, id := range order {
+		resp.Transactions[i] = []byte(id)
+	}
+	return resp, nil
+}
+
+// GetDagNode implements Spore.GetDagNode
+func (s *server) GetDagNode(ctx context.Context, in *TransactionId) (*DagNode, error) {
+	id := string(in.GetTransactionId())
+
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+
+	if exists, _ := s.chain.graph.NodeExists(id); !exists {
+		return nil, fmt.Errorf("transaction %x is not in the DAG", in.GetTransactionId())
+	}
+	parents, err := s.chain.graph.Parents(id)
+	if err != nil {
+		return nil, err
+	}
+	height, err := s.chain.graph.Height(id)
+	if err != nil {
+		return nil, err
+	}
+	blueScore, err := s.chain.graph.BlueScore(id)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &DagNode{
+		Id:        in.GetTransactionId(),
+		Parents:   make([][]byte, len(parents)),
+		Height:    int64(height),
+		BlueScore: int64(blueScore),
+	}
+	for i, p := range parents {
+		node.Parents[i] = []byte(p)
+	}
+	return node, nil
+}
+
+// GetStatus implements Spore.GetStatus
+func (s *server) GetStatus(ctx context.Context, in *StatusRequest) (*Status, error) {
+	status := &Status{Pending: uint32(s.chain.pool.Len())}
+	if h := s.chain.host; h != nil {
+		status.PeerId = h.ID().Pretty()
+		status.Peers = uint32(len(h.Network().Peers()))
+	}
+
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+
+	status.Transactions = uint64(len(s.chain.graph.Nodes()))
+	status.ExecutedIndex = s.chain.executedIndex
+	tips, err := s.chain.graph.Tips()
+	if err != nil {
+		return nil, err
+	}
+	status.Tips = uint32(len(tips))
+
+	if tip := s.chain.graph.ColoringTip(); tip != "" {
+		status.ColoringTip = []byte(tip)
+		blueScore, _ := s.chain.graph.BlueScore(tip)
+		height, _ := s.chain.graph.Height(tip)
+		status.BlueScore = int64(blueScore)
+		status.Height = int64(height)
+	}
+	return status, nil
+}
+
+// GetPeers implements Spore.GetPeers
+func (s *server) GetPeers(ctx context.Context, in *PeersRequest) (*PeersResponse, error) {
+	resp := &PeersResponse{}
+	h := s.chain.host
+	if h == nil {
+		return resp, nil
+	}
+
+	for _, p := range h.Network().Peers() {
+		peer := &Peer{Id: p.Pretty()}
+		for _, addr := range h.Peerstore().Addrs(p) {
+			peer.Addresses = append(peer.Addresses, addr.String())
+		}
+		resp.Peers = append(resp.Peers, peer)
+	}
+	return resp, nil
+}
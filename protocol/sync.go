@@ -0,0 +1,281 @@
+package protocol
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	libp2pprotocol "github.com/libp2p/go-libp2p-core/protocol"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// SyncProtocol is the stream protocol used to fetch missing DAG history
+	SyncProtocol = libp2pprotocol.ID("/spore/sync/1.0.0")
+
+	// maxSyncMessageSize bounds a single length-delimited sync message
+	maxSyncMessageSize = 16 << 20
+
+	syncTimeout = 10 * time.Minute
+)
+
+// startSync registers the sync protocol handler on the host of the chain and
+// syncs the DAG with every connected peer, then again with each peer as it
+// (re)connects.
+func (c *Chain) startSync(ctx context.Context) {
+	c.syncingMu.Lock()
+	c.streams = make(map[network.Stream]struct{})
+	c.syncingMu.Unlock()
+	c.host.SetStreamHandler(SyncProtocol, c.handleSync)
+
+	syncPeer := func(p peer.ID) {
+		if ctx.Err() != nil {
+			return
+		}
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.SyncWithPeer(ctx, p)
+		}()
+	}
+
+	c.notifiee = &network.NotifyBundle{
+		ConnectedF: func(n network.Network, conn network.Conn) {
+			syncPeer(conn.RemotePeer())
+		},
+	}
+	c.host.Network().Notify(c.notifiee)
+
+	for _, p := range c.host.Network().Peers() {
+		syncPeer(p)
+	}
+}
+
+// SyncWithPeer sends our tips to the peer, then validates and adds the missing
+// transactions it streams back. Only one sync runs per peer at a time.
+func (c *Chain) SyncWithPeer(ctx context.Context, p peer.ID) {
+	c.syncingMu.Lock()
+	if _, ok := c.syncing[p]; ok {
+		c.syncingMu.Unlock()
+		return
+	}
+	c.syncing[p] = struct{}{}
+	c.syncingMu.Unlock()
+
+	defer func() {
+		c.syncingMu.Lock()
+		delete(c.syncing, p)
+		c.syncingMu.Unlock()
+	}()
+
+	added, err := c.syncWithPeer(ctx, p)
+	if err != nil {
+		log.Debugf("failed to sync with peer %s: %s", p.Pretty(), err)
+		return
+	}
+	if added > 0 {
+		fmt.Printf("🔄 Synced %d transactions from peer %s\n", added, p.Pretty())
+	}
+}
+
+func (c *Chain) syncWithPeer(ctx context.Context, p peer.ID) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+
+	s, err := c.host.NewStream(ctx, p, SyncProtocol)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+	s.SetDeadline(time.Now().Add(syncTimeout))
+
+	// abort the transfer when the chain stops
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Reset()
+		case <-done:
+		}
+	}()
+
+	c.mu.Lock()
+	tips, err := c.graph.Tips()
+	c.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	req := &SyncRequest{Tips: make([][]byte, len(tips))}
+	for i, tip := range tips {
+		req.Tips[i] = []byte(tip)
+	}
+
+	w := bufio.NewWriter(s)
+	err = writeDelimited(w, req)
+	if err != nil {
+		return 0, err
+	}
+	err = w.Flush()
+	if err != nil {
+		return 0, err
+	}
+	s.CloseWrite()
+
+	added := 0
+	r := bufio.NewReader(s)
+	for {
+		txn := &Transaction{}
+		err = readDelimited(r, txn)
+		if err == io.EOF {
+			return added, nil
+		}
+		if err != nil {
+			return added, err
+		}
+
+		err = c.checkSize(txn)
+		if err == nil {
+			err = c.checkRoot(txn)
+		}
+		if err == nil {
+			err = verifyTransaction(txn)
+		}
+		if err != nil {
+			return added, fmt.Errorf("invalid transaction %x: %s", txn.Id, err)
+		}
+
+		c.addBlockFrom(txn, p)
+		added++
+	}
+}
+
+// handleSync serves an inbound sync stream, tracked so that Stop can reset it
+// and wait for its handler to finish
+func (c *Chain) handleSync(s network.Stream) {
+	c.syncingMu.Lock()
+	if c.streams == nil {
+		c.syncingMu.Unlock()
+		s.Reset()
+		return
+	}
+	c.streams[s] = struct{}{}
+	c.wg.Add(1)
+	c.syncingMu.Unlock()
+
+	defer func() {
+		c.syncingMu.Lock()
+		delete(c.streams, s)
+		c.syncingMu.Unlock()
+		c.wg.Done()
+	}()
+	c.syncHandler(s)
+}
+
+// closeSyncStreams resets the inbound sync streams being served and rejects
+// new ones
+func (c *Chain) closeSyncStreams() {
+	c.syncingMu.Lock()
+	defer c.syncingMu.Unlock()
+	for s := range c.streams {
+		s.Reset()
+	}
+	c.streams = nil
+}
+
+// syncHandler answers a SyncRequest with the transactions missing from the
+// requesting node's DAG, in topological order.
+func (c *Chain) syncHandler(s network.Stream) {
+	defer s.Close()
+	s.SetDeadline(time.Now().Add(syncTimeout))
+
+	req := &SyncRequest{}
+	err := readDelimited(bufio.NewReader(s), req)
+	if err != nil {
+		log.Debugf("failed to read sync request from %s: %s", s.Conn().RemotePeer().Pretty(), err)
+		s.Reset()
+		return
+	}
+
+	tips := make([]string, len(req.Tips))
+	for i, tip := range req.Tips {
+		tips[i] = string(tip)
+	}
+
+	c.mu.Lock()
+	missing, err := c.graph.GetMissingNodes(tips)
+	c.mu.Unlock()
+	if err != nil {
+		log.Errorf("❌ failed to get missing nodes: %s", err)
+		s.Reset()
+		return
+	}
+
+	w := bufio.NewWriter(s)
+	for _, id := range missing {
+		txn, err := c.getTransaction([]byte(id))
+		if err != nil {
+			log.Errorf("❌ failed to load transaction %x for sync: %s", id, err)
+			s.Reset()
+			return
+		}
+
+		err = writeDelimited(w, txn)
+		if err != nil {
+			log.Debugf("failed to send transaction %x: %s", id, err)
+			s.Reset()
+			return
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		log.Debugf("failed to flush sync stream: %s", err)
+		s.Reset()
+	}
+}
+
+// writeDelimited writes a varint length-prefixed protobuf message
+func writeDelimited(w io.Writer, msg proto.Message) error {
+	msgBytes, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	lenBytes := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(lenBytes, uint64(len(msgBytes)))
+	_, err = w.Write(lenBytes[:n])
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(msgBytes)
+	return err
+}
+
+// readDelimited reads a varint length-prefixed protobuf message. It returns
+// io.EOF if the stream ended cleanly before the message.
+func readDelimited(r *bufio.Reader, msg proto.Message) error {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+	if size > maxSyncMessageSize {
+		return errors.New("sync message too large")
+	}
+
+	msgBytes := make([]byte, size)
+	_, err = io.ReadFull(r, msgBytes)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(msgBytes, msg)
+}
@@ -0,0 +1,71 @@
+package protocol
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+func newTestHost(t *testing.T, ctx context.Context) host.Host {
+	h, err := libp2p.New(ctx, libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatalf("failed to create host: %s", err)
+	}
+	return h
+}
+
+func TestStop_SyncStreams(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c, cleanup := newTestChain(t)
+	defer cleanup()
+
+	h := newTestHost(t, ctx)
+	defer h.Close()
+	ps, err := pubsub.NewGossipSub(ctx, h)
+	if err != nil {
+		t.Fatalf("failed to create pubsub: %s", err)
+	}
+	if err = c.Start(ctx, h, ps); err != nil {
+		t.Fatalf("failed to start chain: %s", err)
+	}
+
+	// a peer starts a sync request and never finishes it
+	other := newTestHost(t, ctx)
+	defer other.Close()
+	if err = other.Connect(ctx, peer.AddrInfo{ID: h.ID(), Addrs: h.Addrs()}); err != nil {
+		t.Fatalf("failed to connect hosts: %s", err)
+	}
+	s, err := other.NewStream(ctx, h.ID(), SyncProtocol)
+	if err != nil {
+		t.Fatalf("failed to open sync stream: %s", err)
+	}
+	if _, err = s.Write([]byte{8}); err != nil {
+		t.Fatalf("failed to write sync request: %s", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		c.syncingMu.Lock()
+		served := len(c.streams)
+		c.syncingMu.Unlock()
+		if served == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected sync stream to be served")
+		}
+	}
+
+	if err = c.Stop(); err != nil {
+		t.Fatalf("failed to stop chain: %s", err)
+	}
+	s.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err = s.Read(make([]byte, 1)); err == nil || os.IsTimeout(err) {
+		t.Errorf("expected sync stream to be reset when the chain stops, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package protocol
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pubsubpb "github.com/libp2p/go-libp2p-pubsub/pb"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// MaxParents is the most parents a transaction may reference
+	MaxParents = 64
+)
+
+// MessageID identifies pubsub messages carrying a single transaction by the
+// hash of the request type, the transaction hash, its signature, scheme and
+// public key, so that a transaction submitted to several nodes is only
+// delivered once. The signature is part of the id because pubsub marks an id
+// as seen before validating the message: a copy of a transaction with a bad
+// signature must not keep the valid one from being delivered. Other messages,
+// batches included, fall back to the default message id: a transaction
+// submitted in batches to several nodes is delivered once per batch, and its
+// duplicates are dropped by addPending.
+func MessageID(pmsg *pubsubpb.Message) string {
+	req := &Request{}
+	err := proto.Unmarshal(pmsg.Data, req)
+	if err != nil || req.Transaction == nil {
+		return pubsub.DefaultMsgIdFn(pmsg)
+	}
+	switch req.Type {
+	case Request_SEND_TRANSACTION, Request_CREATE_CONTRACT:
+	default:
+		return pubsub.DefaultMsgIdFn(pmsg)
+	}
+
+	txn := req.Transaction
+	buf := appendUint32(nil, uint32(req.Type))
+	buf = appendBytes(buf, TransactionHash(txn))
+	buf = appendBytes(buf, txn.Signature)
+	buf = appendUint32(buf, uint32(txn.Scheme))
+	buf = appendBytes(buf, txn.PublicKey)
+	hash := sha256.Sum256(buf)
+	return string(hash[:])
+}
+
+func (c *Chain) validateMessage(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	req := &Request{}
+	err := proto.Unmarshal(msg.Data, req)
+	if err == nil {
+		err = c.validateRequest(req)
+	}
+	if err != nil {
+		log.Debugf("rejected message from %s: %s", from.Pretty(), err)
+		return pubsub.ValidationReject
+	}
+	return pubsub.ValidationAccept
+}
+
+// validateRequest checks a request received from the network
+func (c *Chain) validateRequest(req *Request) error {
+	switch req.Type {
+	case Request_CREATE_CONTRACT:
+		return c.validateTransaction(req.Transaction, true)
+	case Request_SEND_TRANSACTION:
+		return c.validateTransaction(req.Transaction, false)
+	case Request_BATCH:
+		if len(req.Transactions) == 0 {
+			return errors.New("batch has no transactions")
+		}
+		if maxBatchSize := c.conf.RPC.MaxBatchSize; len(req.Transactions) > maxBatchSize {
+			return fmt.Errorf("batch of %d transactions exceeds the maximum of %d", len(req.Transactions), maxBatchSize)
+		}
+		for _, txn := range req.Transactions {
+			err := c.validateTransaction(txn, len(txn.GetTo()) == 0)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown request type %d", req.Type)
+	}
+}
+
+// validateTransaction checks a transaction received from the network
+func (c *Chain) validateTransaction(txn *Transaction, create bool) error {
+	if txn == nil {
+		return errors.New("request has no transaction")
+	}
+	if create && len(txn.To) > 0 {
+		return errors.New("contract creation must not have a recipient")
+	}
+	if !create && len(txn.To) == 0 {
+		return errors.New("transaction has no recipient")
+	}
+
+	err := c.checkSize(txn)
+	if err == nil {
+		err = c.checkRoot(txn)
+	}
+	if err != nil {
+		return err
+	}
+	return verifyTransaction(txn)
+}
+
+// checkRoot enforces that only the first transaction of an empty DAG omits its
+// parents
+func (c *Chain) checkRoot(txn *Transaction) error {
+	if len(txn.Parents) == 0 && c.graphStore.Count() > 0 {
+		return errors.New("transaction does not reference any parents")
+	}
+	return nil
+}
+
+// checkSize enforces the size limits of a transaction: contracts.maxWasmSize
+// for the code of deployments, contracts.maxDataSize for the data of calls
+func (c *Chain) checkSize(txn *Transaction) error {
+	limits := c.conf.Contracts
+	if len(txn.To) == 0 && len(txn.Data) > limits.MaxWasmSize {
+		return fmt.Errorf("contract code of %d bytes exceeds the maximum of %d", len(txn.Data), limits.MaxWasmSize)
+	}
+	if len(txn.To) > 0 && len(txn.Data) > limits.MaxDataSize {
+		return fmt.Errorf("data of %d bytes exceeds the maximum of %d", len(txn.Data), limits.MaxDataSize)
+	}
+	if len(txn.Parents) > MaxParents {
+		return fmt.Errorf("%d parents exceed the maximum of %d", len(txn.Parents), MaxParents)
+	}
+	for _, p := range txn.Parents {
+		if len(p) != sha256.Size {
+			return fmt.Errorf("invalid parent id of %d bytes", len(p))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pubsubpb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/sporeframework/spore/config"
+	"github.com/sporeframework/spore/dag"
+	"google.golang.org/protobuf/proto"
+)
+
+// testChain is a chain with the default configuration and an empty DAG, enough
+// to validate requests without a database
+var testChain = &Chain{conf: config.Default(), graphStore: &dag.GraphStore{}}
+
+// signedRequest returns a request with a transaction signed and given its
+// metadata the way the RPC server does
+func signedRequest(t *testing.T, txn *Transaction) *Request {
+	prv, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	txn.From = crypto.PubkeyToAddress(prv.PublicKey).Bytes()
+
+	txn.Signature, err = crypto.Sign(TransactionHash(txn), prv)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %s", err)
+	}
+	setMetadata(txn)
+
+	return &Request{Type: Request_SEND_TRANSACTION, Transaction: txn}
+}
+
+func TestValidateRequest(t *testing.T) {
+	req := signedRequest(t, &Transaction{To: make([]byte, 32), Data: []byte("increment"), Gas: 1000})
+	if err := testChain.validateRequest(req); err != nil {
+		t.Fatalf("expected valid request, got %s", err)
+	}
+
+	tampered := proto.Clone(req).(*Request)
+	tampered.Transaction.Data = []byte("decrement")
+	if err := testChain.validateRequest(tampered); err == nil {
+		t.Error("expected tampered transaction to be rejected")
+	}
+
+	wrongType := proto.Clone(req).(*Request)
+	wrongType.Type = Request_CREATE_CONTRACT
+	if err := testChain.validateRequest(wrongType); err == nil {
+		t.Error("expected contract creation with a recipient to be rejected")
+	}
+
+	if err := testChain.validateRequest(&Request{}); err == nil {
+		t.Error("expected request without a transaction to be rejected")
+	}
+}
+
+func TestValidateRequest_Size(t *testing.T) {
+	req := signedRequest(t, &Transaction{To: make([]byte, 32), Data: make([]byte, testChain.conf.Contracts.MaxDataSize+1), Gas: 1000})
+	if err := testChain.validateRequest(req); err == nil {
+		t.Error("expected oversized data to be rejected")
+	}
+
+	req = signedRequest(t, &Transaction{To: make([]byte, 32), Parents: [][]byte{[]byte("short")}, Gas: 1000})
+	if err := testChain.validateRequest(req); err == nil {
+		t.Error("expected malformed parent id to be rejected")
+	}
+}
+
+func TestValidateRequest_Parents(t *testing.T) {
+	c, cleanup := newTestChain(t)
+	defer cleanup()
+
+	genesis := signedRequest(t, &Transaction{To: make([]byte, 32), Gas: 1000})
+	if err := c.validateRequest(genesis); err != nil {
+		t.Fatalf("expected first transaction of an empty DAG to be valid, got %s", err)
+	}
+	c.AddBlock(genesis.Transaction)
+
+	root := signedRequest(t, &Transaction{To: make([]byte, 32), Gas: 1000})
+	if err := c.validateRequest(root); err == nil {
+		t.Error("expected transaction without parents to be rejected once the DAG has a genesis")
+	}
+	c.AddBlock(root.Transaction)
+	if exists, _ := c.graph.NodeExists(string(root.Transaction.Id)); exists {
+		t.Error("expected transaction without parents not to be added to the DAG")
+	}
+}
+
+func TestMessageID(t *testing.T) {
+	req := signedRequest(t, &Transaction{To: make([]byte, 32), Data: []byte("increment"), Gas: 1000})
+
+	// the same transaction submitted to two nodes only differs in metadata
+	other := proto.Clone(req).(*Request)
+	other.Transaction.Created++
+
+	data, _ := proto.Marshal(req)
+	otherData, _ := proto.Marshal(other)
+	id := MessageID(&pubsubpb.Message{Data: data})
+	if MessageID(&pubsubpb.Message{Data: otherData}) != id {
+		t.Error("expected the same transaction to have the same message id")
+	}
+
+	// a copy with a garbage signature must not take the id of the original,
+	// which pubsub marks as seen before validating the copy
+	forged := proto.Clone(req).(*Request)
+	forged.Transaction.Signature = make([]byte, len(req.Transaction.Signature))
+	forgedData, _ := proto.Marshal(forged)
+	if MessageID(&pubsubpb.Message{Data: forgedData}) == id {
+		t.Error("expected a transaction with another signature to have another message id")
+	}
+	contract := proto.Clone(req).(*Request)
+	contract.Type = Request_CREATE_CONTRACT
+	contractData, _ := proto.Marshal(contract)
+	if MessageID(&pubsubpb.Message{Data: contractData}) == id {
+		t.Error("expected a request of another type to have another message id")
+	}
+}
+
+func TestMessageID_Batch(t *testing.T) {
+	c, cleanup := newTestChain(t)
+	defer cleanup()
+	req := signedRequest(t, &Transaction{To: make([]byte, 32), Data: []byte("increment"), Gas: 1000})
+
+	// batches bypass the message id of their transactions, so duplicates are
+	// dropped when they reach the mempool
+	data, _ := proto.Marshal(req)
+	id := MessageID(&pubsubpb.Message{Data: data})
+	batch := &pubsubpb.Message{}
+	batch.Data, _ = proto.Marshal(&Request{Type: Request_BATCH, Transactions: []*Transaction{req.Transaction}})
+	if MessageID(batch) == id {
+		t.Error("expected a batch not to have the message id of its transaction")
+	}
+	// nor a batch carrying a stray transaction field
+	stray := &pubsubpb.Message{}
+	stray.Data, _ = proto.Marshal(&Request{Type: Request_BATCH, Transaction: req.Transaction})
+	if MessageID(stray) != pubsub.DefaultMsgIdFn(stray) {
+		t.Error("expected a batch with a transaction field to have the default message id")
+	}
+	if err := c.addPending(req.Transaction, ""); err != nil {
+		t.Fatalf("failed to add pending transaction: %s", err)
+	}
+	if err := c.addPending(req.Transaction, ""); err != errDuplicateTransaction {
+		t.Errorf("expected duplicate of a pending transaction to be dropped, got %v", err)
+	}
+	c.AddBlock(c.pool.Take(1)[0])
+	if err := c.addPending(req.Transaction, ""); err != errDuplicateTransaction {
+		t.Errorf("expected duplicate of a transaction in the DAG to be dropped, got %v", err)
+	}
+}
+
+func TestValidateRequest_Batch(t *testing.T) {
+	send := signedRequest(t, &Transaction{To: make([]byte, 32), Data: []byte("increment"), Gas: 1000})
+	create := signedRequest(t, &Transaction{Data: []byte("wasm"), Gas: 1000})
+	batch := &Request{
+		Type:         Request_BATCH,
+		Transactions: []*Transaction{send.Transaction, create.Transaction},
+	}
+	if err := testChain.validateRequest(batch); err != nil {
+		t.Fatalf("expected valid batch, got %s", err)
+	}
+
+	tampered := proto.Clone(batch).(*Request)
+	tampered.Transactions[1].Data = []byte("other")
+	if err := testChain.validateRequest(tampered); err == nil {
+		t.Error("expected batch with a tampered transaction to be rejected")
+	}
+
+	if err := testChain.validateRequest(&Request{Type: Request_BATCH}); err == nil {
+		t.Error("expected empty batch to be rejected")
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"crypto/sha256"
 	"encoding/hex"
 	"flag"
 	"fmt"
@@ -15,12 +14,8 @@ import (
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/golang/protobuf/proto"
-	pb "github.com/sporeframework/spore/protocol"
+	"github.com/sporeframework/spore/client"
 	"golang.org/x/crypto/sha3"
-	"google.golang.org/grpc"
-
-	"math/rand"
 )
 
 func generateRandomKey() (address []byte, privateKey *ecdsa.PrivateKey) {
@@ -51,108 +46,42 @@ func main() {
 
 	addr := "localhost:" + strconv.Itoa(*rpcPort)
 	fmt.Println("connecting to ", addr)
-	// Set up a connection to the server.
-	conn, err := grpc.Dial(addr, grpc.WithInsecure(), grpc.WithBlock())
-	if err != nil {
-		log.Fatalf("did not connect: %v", err)
-	}
-	defer conn.Close()
-
-	c := pb.NewSporeClient(conn)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	address, privateKey := generateRandomKey()
-
-	contractID := createContract(c, ctx, address, privateKey)
-
-	// contractID, _ := hex.DecodeString("f5b012bbab7f165bc5eec4302f0952c1f3f8b601d4907cb8c5ae781a71821abb")
-
-	createTransaction(c, ctx, contractID[:], address, privateKey)
-	id, _ := hex.DecodeString("4c2f990741c8957c7c60ab76655ccb12f35d7db05334c51a00f62cc820861265")
-	getTransaction(c, ctx, id)
-	/*
-		for i := 0; i < 500; i++ {
-			rand.Int31()
-			createTransaction(c, ctx, contractID[:], address, privateKey)
-			fmt.Println(i)
-		}
-	*/
-}
-
-func getTransaction(c pb.SporeClient, ctx context.Context, id []byte) *pb.Transaction {
-
-	r, err := c.GetTransaction(ctx, &pb.TransactionId{TransactionId: id})
+	// Set up a connection to the server.
+	c, err := client.Dial(ctx, []string{addr})
 	if err != nil {
-		log.Fatalf("could not send: %v", err)
+		log.Fatalf("did not connect: %v", err)
 	}
-	log.Printf("Transaction: %s", r)
-	return r
+	defer c.Close()
 
-}
+	_, privateKey := generateRandomKey()
+	signer := client.NewSigner(privateKey)
 
-func createContract(c pb.SporeClient, ctx context.Context, address []byte, prv *ecdsa.PrivateKey) [32]byte {
 	wasm, err := ioutil.ReadFile("./increment.wasm")
 	if err != nil {
 		panic(err)
 	}
 
-	// create the transaction
-	txn := &pb.Transaction{
-		Data:     wasm,
-		From:     address,
-		Contract: true,
-		Nonce:    rand.Int31(),
-	}
-
-	// sign the transaction
-	txnBytes, _ := proto.Marshal(txn)
-	pSum := sha256.Sum256(txnBytes)
-	fmt.Printf("pSum: %s\n", hex.EncodeToString(pSum[:]))
-	sig, err := crypto.Sign(pSum[:], prv)
-	txn.Signature = sig
-	fmt.Printf("sig: %s\n", hex.EncodeToString(sig))
-
 	// create contract transaction
-	r, err := c.CreateContract(ctx, txn)
+	txnID, contractID, err := c.Deploy(ctx, signer, wasm, 100000)
 	if err != nil {
 		log.Fatalf("could not send: %v", err)
 	}
-	log.Printf("Reply: %s", string(hex.EncodeToString(r.GetTransactionId())))
+	log.Printf("Reply: %s", hex.EncodeToString(txnID))
+	fmt.Printf("sha256: %x\n", contractID)
 
-	sum := sha256.Sum256(wasm)
-	fmt.Printf("sha256: %x\n", sum)
-	return sum
-}
-
-func createTransaction(c pb.SporeClient, ctx context.Context, contractID []byte, address []byte, prv *ecdsa.PrivateKey) []byte {
-	payload := []byte("increment")
-
-	// create the transaction
-	txn := &pb.Transaction{
-		Data:     payload,
-		To:       contractID,
-		From:     address,
-		Contract: true,
-		Nonce:    rand.Int31(),
-	}
-	// sign the transaction
-	txnBytes, _ := proto.Marshal(txn)
-	pSum := sha256.Sum256(txnBytes)
-	fmt.Printf("pSum: %s\n", hex.EncodeToString(pSum[:]))
-	sig, err := crypto.Sign(pSum[:], prv)
-	txn.Signature = sig
-	//fmt.Printf("sig: %s\n", hex.EncodeToString(sig))
-
-	txnBytes, _ = proto.Marshal(txn)
-	txnHash := sha256.Sum256(txnBytes)
-	fmt.Printf("txnId: %s\n", hex.EncodeToString(txnHash[:]))
-
-	r, err := c.Send(ctx, txn)
+	txnID, err = c.Call(ctx, signer, contractID[:], 100000, "increment")
 	if err != nil {
 		log.Fatalf("could not send: %v", err)
 	}
-	log.Printf("Reply: %s", string(hex.EncodeToString(r.GetTransactionId())))
-	return r.GetTransactionId()
+	log.Printf("Reply: %s", hex.EncodeToString(txnID))
+
+	receipt, err := c.WaitForReceipt(ctx, txnID)
+	if err != nil {
+		log.Fatalf("could not get receipt: %v", err)
+	}
+	log.Printf("Receipt: %s", receipt)
 }